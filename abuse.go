@@ -0,0 +1,258 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	abuseReasonSequentialScan = "sequential_scan"
+	abuseReasonErrorRatio     = "error_ratio"
+
+	// maxSequentialStep is the largest gap between consecutive CEPs still
+	// counted as walking the CEP range.
+	maxSequentialStep = 100
+)
+
+// AbusePolicy configures abuse detection. A zero Window disables it; a zero
+// BlockFor only flags clients for review without blocking them.
+type AbusePolicy struct {
+	Window        time.Duration
+	MinRequests   int
+	MaxErrorRatio float64
+	SequentialRun int
+	BlockFor      time.Duration
+}
+
+type AbuseFlag struct {
+	Client       string     `json:"client"`
+	Reason       string     `json:"reason"`
+	FlaggedAt    time.Time  `json:"flagged_at"`
+	BlockedUntil *time.Time `json:"blocked_until,omitempty"`
+	Requests     int        `json:"requests"`
+	Errors       int        `json:"errors"`
+}
+
+type clientActivity struct {
+	requests int
+	errors   int
+	lastCEP  int
+	run      int
+}
+
+// abuseDetector counts weather lookups per client in fixed windows and flags
+// clients that walk the CEP range or whose lookups mostly fail. Activity is
+// dropped at every window boundary, so memory stays bounded by the clients
+// seen in one window; flags are kept until an admin clears them.
+type abuseDetector struct {
+	mu          sync.Mutex
+	policy      AbusePolicy
+	windowStart time.Time
+	activity    map[string]*clientActivity
+	flags       map[string]*AbuseFlag
+	now         func() time.Time
+	// proxies decides whether X-Forwarded-For identifies the client.
+	proxies trustedProxies
+}
+
+func newAbuseDetector(policy AbusePolicy) *abuseDetector {
+	return &abuseDetector{
+		policy:   policy,
+		activity: make(map[string]*clientActivity),
+		flags:    make(map[string]*AbuseFlag),
+		now:      time.Now,
+	}
+}
+
+// record accounts for one finished lookup and flags the client if it crossed
+// a threshold.
+func (d *abuseDetector) record(client, cep string, status int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := d.now()
+	if now.Sub(d.windowStart) >= d.policy.Window {
+		d.windowStart = now
+		d.activity = make(map[string]*clientActivity)
+	}
+	a, ok := d.activity[client]
+	if !ok {
+		a = &clientActivity{}
+		d.activity[client] = a
+	}
+	a.requests++
+	if status >= http.StatusBadRequest && status < http.StatusInternalServerError {
+		a.errors++
+	}
+	if n, err := strconv.Atoi(cep); err == nil && len(cep) == 8 {
+		if step := n - a.lastCEP; a.lastCEP != 0 && step > 0 && step <= maxSequentialStep {
+			a.run++
+		} else {
+			a.run = 0
+		}
+		a.lastCEP = n
+	}
+
+	if _, flagged := d.flags[client]; flagged {
+		return
+	}
+	reason := ""
+	switch {
+	case d.policy.SequentialRun > 0 && a.run >= d.policy.SequentialRun:
+		reason = abuseReasonSequentialScan
+	case a.requests >= d.policy.MinRequests && float64(a.errors) >= d.policy.MaxErrorRatio*float64(a.requests):
+		reason = abuseReasonErrorRatio
+	default:
+		return
+	}
+	flag := &AbuseFlag{Client: client, Reason: reason, FlaggedAt: now, Requests: a.requests, Errors: a.errors}
+	if d.policy.BlockFor > 0 {
+		until := now.Add(d.policy.BlockFor)
+		flag.BlockedUntil = &until
+	}
+	d.flags[client] = flag
+	slog.Warn("ALERT: abusive client flagged", "client", client, "reason", reason, "requests", a.requests, "errors", a.errors, "blocked", flag.BlockedUntil != nil)
+}
+
+// blockedFor returns how long client remains blocked, or zero.
+func (d *abuseDetector) blockedFor(client string) time.Duration {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	flag, ok := d.flags[client]
+	if !ok || flag.BlockedUntil == nil {
+		return 0
+	}
+	if remaining := flag.BlockedUntil.Sub(d.now()); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+func (d *abuseDetector) list() []AbuseFlag {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	flags := make([]AbuseFlag, 0, len(d.flags))
+	for _, flag := range d.flags {
+		flags = append(flags, *flag)
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].FlaggedAt.Before(flags[j].FlaggedAt) })
+	return flags
+}
+
+// clear lifts a flag (and its block) and resets the client's counters.
+func (d *abuseDetector) clear(client string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, ok := d.flags[client]
+	delete(d.flags, client)
+	delete(d.activity, client)
+	return ok
+}
+
+// trustedProxies lists the addresses (TRUSTED_PROXIES) allowed to report the
+// caller in X-Forwarded-For. Any other peer could forge the header, so its
+// own address is used instead.
+type trustedProxies []netip.Prefix
+
+// parseTrustedProxies accepts a comma-separated list of IPs and CIDRs.
+func parseTrustedProxies(raw string) (trustedProxies, error) {
+	var proxies trustedProxies
+	for _, item := range parseList(raw) {
+		if prefix, err := netip.ParsePrefix(item); err == nil {
+			proxies = append(proxies, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(item)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TRUSTED_PROXIES entry %q", item)
+		}
+		addr = addr.Unmap()
+		proxies = append(proxies, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return proxies, nil
+}
+
+func (p trustedProxies) trusts(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range p {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientAddress identifies the caller by IP. X-Forwarded-For is honoured only
+// when the connection comes from a trusted proxy; its hops are then walked
+// from the right, skipping trusted proxies, so the first untrusted hop is the
+// address the outermost proxy saw.
+func (p trustedProxies) clientAddress(r *http.Request) string {
+	peer, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		peer = r.RemoteAddr
+	}
+	if !p.trusts(peer) {
+		return peer
+	}
+	client := peer
+	hops := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		client = hop
+		if !p.trusts(hop) {
+			break
+		}
+	}
+	return client
+}
+
+func (d *abuseDetector) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client := d.proxies.clientAddress(r)
+		if wait := d.blockedFor(client); wait > 0 {
+			writeRetryAfter(w, wait)
+			writeJSON(w, http.StatusTooManyRequests, ErrorResponse{Message: "client temporarily blocked"})
+			return
+		}
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+		d.record(client, normalizeCEP(mux.Vars(r)["cep"]), recorder.status)
+	})
+}
+
+func (app *App) handleAbuseFlags(w http.ResponseWriter, r *http.Request) {
+	flags := []AbuseFlag{}
+	if app.abuse != nil {
+		flags = app.abuse.list()
+	}
+	writeJSON(w, http.StatusOK, flags)
+}
+
+func (app *App) handleAbuseClear(w http.ResponseWriter, r *http.Request) {
+	client := mux.Vars(r)["client"]
+	if app.abuse == nil || !app.abuse.clear(client) {
+		writeJSON(w, http.StatusNotFound, ErrorResponse{Message: "client not flagged"})
+		return
+	}
+	slog.InfoContext(r.Context(), "Admin cleared abuse flag", "client", client)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAbuseDetector(t *testing.T) {
+	now := time.Date(2024, 5, 17, 13, 0, 0, 0, time.UTC)
+	policy := AbusePolicy{Window: time.Minute, MinRequests: 10, MaxErrorRatio: 0.8, SequentialRun: 5}
+	newDetector := func(policy AbusePolicy) *abuseDetector {
+		d := newAbuseDetector(policy)
+		d.now = func() time.Time { return now }
+		return d
+	}
+
+	t.Run("Varredura sequencial de CEPs", func(t *testing.T) {
+		d := newDetector(policy)
+		for i := 0; i < 6; i++ {
+			d.record("10.0.0.1", fmt.Sprintf("%08d", 1310100+i*10), http.StatusOK)
+		}
+		flags := d.list()
+		if len(flags) != 1 || flags[0].Reason != abuseReasonSequentialScan {
+			t.Fatalf("Expected a sequential_scan flag, got %+v", flags)
+		}
+		if d.blockedFor("10.0.0.1") != 0 {
+			t.Error("Expected flag-only policy not to block")
+		}
+	})
+
+	t.Run("Proporção alta de erros", func(t *testing.T) {
+		d := newDetector(policy)
+		for i := 0; i < 10; i++ {
+			d.record("10.0.0.2", "99999999", http.StatusNotFound)
+		}
+		if flags := d.list(); len(flags) != 1 || flags[0].Reason != abuseReasonErrorRatio || flags[0].Errors != 10 {
+			t.Fatalf("Expected an error_ratio flag, got %+v", flags)
+		}
+	})
+
+	t.Run("Tráfego normal não é sinalizado", func(t *testing.T) {
+		d := newDetector(policy)
+		for _, cep := range []string{"01310100", "20040002", "04538133", "01310100", "30130000", "20040002"} {
+			d.record("10.0.0.3", cep, http.StatusOK)
+		}
+		d.record("10.0.0.3", "99999999", http.StatusNotFound)
+		if flags := d.list(); len(flags) != 0 {
+			t.Errorf("Expected no flags, got %+v", flags)
+		}
+	})
+
+	t.Run("Janela nova zera os contadores", func(t *testing.T) {
+		d := newDetector(policy)
+		for i := 0; i < 9; i++ {
+			d.record("10.0.0.4", "99999999", http.StatusNotFound)
+		}
+		now = now.Add(time.Minute)
+		d.record("10.0.0.4", "99999999", http.StatusNotFound)
+		if flags := d.list(); len(flags) != 0 {
+			t.Errorf("Expected counters to reset with the window, got %+v", flags)
+		}
+	})
+
+	t.Run("Bloqueio expira e pode ser removido", func(t *testing.T) {
+		d := newDetector(AbusePolicy{Window: time.Minute, MinRequests: 1, MaxErrorRatio: 0.5, BlockFor: 10 * time.Minute})
+		d.record("10.0.0.5", "99999999", http.StatusNotFound)
+		if wait := d.blockedFor("10.0.0.5"); wait != 10*time.Minute {
+			t.Errorf("Expected 10m block, got %s", wait)
+		}
+		if !d.clear("10.0.0.5") || d.blockedFor("10.0.0.5") != 0 {
+			t.Error("Expected clear to lift the block")
+		}
+		if d.clear("10.0.0.5") {
+			t.Error("Expected clearing an unflagged client to report false")
+		}
+	})
+}
+
+func TestClientAddress(t *testing.T) {
+	proxies, err := parseTrustedProxies("10.0.0.0/8, 192.0.2.1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		expected   string
+	}{
+		{"Sem proxy usa o endereço da conexão", "192.0.2.1:1234", "", "192.0.2.1"},
+		{"Proxy confiável usa o último salto", "192.0.2.1:1234", "203.0.113.9, 198.51.100.7", "198.51.100.7"},
+		{"Saltos confiáveis são ignorados", "10.1.2.3:1234", "203.0.113.9, 10.0.0.7", "203.0.113.9"},
+		{"Conexão não confiável ignora o header", "198.51.100.20:1234", "203.0.113.9", "198.51.100.20"},
+		{"Todos os saltos confiáveis", "10.1.2.3:1234", "10.0.0.7", "10.0.0.7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/weather/01310100", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if got := proxies.clientAddress(r); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	tests := []struct {
+		name        string
+		raw         string
+		expected    int
+		expectedErr bool
+	}{
+		{"Vazio", "", 0, false},
+		{"IPs e CIDRs", "10.0.0.0/8, 192.0.2.1, ::1", 3, false},
+		{"Entrada inválida", "10.0.0.0/8, proxy.local", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxies, err := parseTrustedProxies(tt.raw)
+			if (err != nil) != tt.expectedErr {
+				t.Fatalf("Expected error %v, got %v", tt.expectedErr, err)
+			}
+			if len(proxies) != tt.expected {
+				t.Errorf("Expected %d proxies, got %v", tt.expected, proxies)
+			}
+		})
+	}
+}
+
+func TestAbuseBlocking(t *testing.T) {
+	env := newE2EEnv(t, func(cfg *Config) {
+		cfg.AdminToken = "admin-secret"
+		cfg.Abuse = AbusePolicy{Window: time.Minute, MinRequests: 3, MaxErrorRatio: 0.8, BlockFor: time.Hour}
+	})
+
+	for i := 0; i < 3; i++ {
+		env.get(t, "/weather/99999999", nil)
+	}
+	resp, _ := env.get(t, "/weather/01310100", nil)
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("Expected status 429 for a blocked client, got %d", resp.StatusCode)
+	}
+	if resp.Header.Get("Retry-After") != "3600" {
+		t.Errorf("Expected Retry-After 3600, got %q", resp.Header.Get("Retry-After"))
+	}
+
+	resp, body := env.get(t, "/admin/abuse", map[string]string{"Authorization": "Bearer admin-secret"})
+	var flags []AbuseFlag
+	if err := json.Unmarshal(body, &flags); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected flag list, got %d %s", resp.StatusCode, body)
+	}
+	if len(flags) != 1 || flags[0].Client != "127.0.0.1" {
+		t.Fatalf("Expected 127.0.0.1 flagged, got %+v", flags)
+	}
+
+	if status := env.do(t, "DELETE", "/admin/abuse/127.0.0.1", "admin-secret"); status != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d", status)
+	}
+	if resp, _ := env.get(t, "/weather/01310100", nil); resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200 after clearing the flag, got %d", resp.StatusCode)
+	}
+}
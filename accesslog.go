@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	accessLogOff      = "off"
+	accessLogJSON     = "json"
+	accessLogCombined = "combined"
+)
+
+// accessLogger writes one line per request: through slog for "json", so it
+// shares LOG_FORMAT and the request ID, or in Apache combined format to out.
+type accessLogger struct {
+	format string
+	mu     sync.Mutex
+	out    io.Writer
+	now    func() time.Time
+	// clientTag, when set, adds the caller's X-Client-Tag to JSON lines.
+	clientTag func(*http.Request) string
+	// proxies decides whether X-Forwarded-For identifies the client.
+	proxies trustedProxies
+}
+
+func newAccessLogger(format string, out io.Writer) *accessLogger {
+	return &accessLogger{format: format, out: out, now: time.Now}
+}
+
+func (l *accessLogger) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := l.now()
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+		latency := l.now().Sub(start)
+
+		if l.format == accessLogCombined {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			fmt.Fprintf(l.out, "%s - - [%s] %q %d %s %q %q\n",
+				l.proxies.clientAddress(r), start.Format("02/Jan/2006:15:04:05 -0700"),
+				r.Method+" "+r.URL.RequestURI()+" "+r.Proto, recorder.status, combinedBytes(recorder.bytes),
+				orDash(r.Referer()), orDash(r.UserAgent()))
+			return
+		}
+		attrs := []any{"method", r.Method, "path", r.URL.Path, "status", recorder.status,
+			"bytes", recorder.bytes, "latency_ms", latency.Milliseconds(), "client_ip", l.proxies.clientAddress(r)}
+		if l.clientTag != nil {
+			attrs = append(attrs, "client_tag", l.clientTag(r))
+		}
+		slog.InfoContext(r.Context(), "HTTP request", attrs...)
+	})
+}
+
+// combinedBytes and orDash follow the Apache convention of "-" for empty
+// fields.
+func combinedBytes(n int) string {
+	if n == 0 {
+		return "-"
+	}
+	return fmt.Sprint(n)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
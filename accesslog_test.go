@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAccessLogger(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusNotFound, ErrorResponse{Message: "can not find zipcode"})
+	})
+	newRequest := func() *http.Request {
+		r := httptest.NewRequest("GET", "/weather/99999999?lang=en", nil)
+		r.Header.Set("User-Agent", "curl/8.0")
+		return r
+	}
+
+	t.Run("Formato combined", func(t *testing.T) {
+		var out bytes.Buffer
+		logger := newAccessLogger(accessLogCombined, &out)
+		logger.now = func() time.Time { return time.Date(2024, 5, 17, 13, 0, 0, 0, time.UTC) }
+		logger.middleware(handler).ServeHTTP(httptest.NewRecorder(), newRequest())
+
+		expected := `192.0.2.1 - - [17/May/2024:13:00:00 +0000] "GET /weather/99999999?lang=en HTTP/1.1" 404 35 "-" "curl/8.0"` + "\n"
+		if out.String() != expected {
+			t.Errorf("Expected %q, got %q", expected, out.String())
+		}
+	})
+
+	t.Run("X-Forwarded-For só de proxy confiável", func(t *testing.T) {
+		var out bytes.Buffer
+		logger := newAccessLogger(accessLogCombined, &out)
+		for _, trusted := range []bool{false, true} {
+			out.Reset()
+			logger.proxies = nil
+			if trusted {
+				logger.proxies, _ = parseTrustedProxies("192.0.2.1")
+			}
+			req := newRequest()
+			req.Header.Set("X-Forwarded-For", "203.0.113.9")
+			logger.middleware(handler).ServeHTTP(httptest.NewRecorder(), req)
+
+			expected := "192.0.2.1 "
+			if trusted {
+				expected = "203.0.113.9 "
+			}
+			if !strings.HasPrefix(out.String(), expected) {
+				t.Errorf("Expected line starting with %q (trusted=%v), got %q", expected, trusted, out.String())
+			}
+		}
+	})
+
+	t.Run("Formato JSON", func(t *testing.T) {
+		var out bytes.Buffer
+		logger, _ := newLogger(&out, "info", "json")
+		defaultLogger := slog.Default()
+		slog.SetDefault(logger)
+		t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+
+		accessLog := newAccessLogger(accessLogJSON, nil)
+		accessLog.clientTag = NewApp(nil, nil).requestClientTag
+		req := newRequest()
+		req.Header.Set(clientTagHeader, "Mobile")
+		accessLog.middleware(handler).ServeHTTP(httptest.NewRecorder(), req)
+
+		var entry map[string]any
+		if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+			t.Fatalf("Expected a JSON line, got %q", out.String())
+		}
+		if entry["msg"] != "HTTP request" || entry["path"] != "/weather/99999999" || entry["status"] != float64(404) ||
+			entry["bytes"] != float64(35) || entry["client_ip"] != "192.0.2.1" || entry["client_tag"] != "mobile" {
+			t.Errorf("Unexpected entry %v", entry)
+		}
+		if _, ok := entry["latency_ms"]; !ok {
+			t.Errorf("Expected latency_ms in %v", entry)
+		}
+	})
+}
+
+func TestAccessLog_CoversUnmatchedRoutes(t *testing.T) {
+	var out bytes.Buffer
+	app := NewApp(nil, nil)
+	app.accessLog = newAccessLogger(accessLogCombined, &out)
+
+	rec := httptest.NewRecorder()
+	app.setupRoutes().ServeHTTP(rec, httptest.NewRequest("GET", "/nope", nil))
+	if rec.Code != http.StatusNotFound || rec.Header().Get(requestIDHeader) == "" {
+		t.Errorf("Expected 404 with a request ID, got %d %q", rec.Code, rec.Header().Get(requestIDHeader))
+	}
+	if !strings.Contains(out.String(), `"GET /nope HTTP/1.1" 404`) {
+		t.Errorf("Expected access log line for /nope, got %q", out.String())
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+type AdminCacheStatsResponse struct {
+	CEP     *CacheStats `json:"cep"`
+	Weather *CacheStats `json:"weather"`
+}
+
+func (app *App) adminAuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(app.adminToken)) != 1 {
+			writeJSON(w, http.StatusUnauthorized, ErrorResponse{Message: "invalid admin token"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (app *App) adminRoutes() []routeSpec {
+	routes := []routeSpec{
+		{Method: http.MethodGet, Path: "/admin/cache", Handler: http.HandlerFunc(app.handleCacheStats)},
+		{Method: http.MethodDelete, Path: "/admin/cache", Handler: http.HandlerFunc(app.handleCacheFlush)},
+		{Method: http.MethodDelete, Path: "/admin/cache/cep/{cep}", Handler: http.HandlerFunc(app.handleCachePurgeCEP)},
+		{Method: http.MethodDelete, Path: "/admin/cache/weather", Handler: http.HandlerFunc(app.handleCachePurgeWeather)},
+		{Method: http.MethodGet, Path: "/admin/breakers", Handler: http.HandlerFunc(app.handleBreakers)},
+		{Method: http.MethodGet, Path: "/admin/snapshot", Handler: http.HandlerFunc(app.handleSnapshotExport)},
+		{Method: http.MethodPost, Path: "/admin/snapshot", Handler: http.HandlerFunc(app.handleSnapshotImport)},
+		{Method: http.MethodGet, Path: "/admin/abuse", Handler: http.HandlerFunc(app.handleAbuseFlags)},
+		{Method: http.MethodDelete, Path: "/admin/abuse/{client}", Handler: http.HandlerFunc(app.handleAbuseClear)},
+	}
+	if app.pprofAdmin {
+		routes = append(routes, routeSpec{Method: http.MethodGet, Path: "/debug/pprof/", Prefix: true, Handler: pprofHandler()})
+	}
+	for i := range routes {
+		routes[i].Admin = true
+		routes[i].Internal = true
+	}
+	return routes
+}
+
+func (app *App) handleCacheStats(w http.ResponseWriter, r *http.Request) {
+	var response AdminCacheStatsResponse
+	if app.cepCache != nil {
+		stats, err := app.cepCache.Stats(r.Context())
+		if err != nil {
+			slog.WarnContext(r.Context(), "Error reading CEP cache stats", "error", err)
+			writeJSON(w, http.StatusBadGateway, ErrorResponse{Message: "error reading cep cache stats"})
+			return
+		}
+		response.CEP = &stats
+	}
+	if app.weatherCache != nil {
+		stats := app.weatherCache.Stats()
+		response.Weather = &stats
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (app *App) handleCacheFlush(w http.ResponseWriter, r *http.Request) {
+	if app.cepCache != nil {
+		if err := app.cepCache.Flush(r.Context()); err != nil {
+			slog.ErrorContext(r.Context(), "Error flushing CEP cache", "error", err)
+			writeJSON(w, http.StatusBadGateway, ErrorResponse{Message: "error flushing cep cache"})
+			return
+		}
+	}
+	if app.weatherCache != nil {
+		app.weatherCache.Purge()
+	}
+	if app.forecastCache != nil {
+		app.forecastCache.Purge()
+	}
+	app.publishInvalidation(r.Context(), invalidation{Scope: invalidateAll})
+	slog.InfoContext(r.Context(), "Admin flushed all caches")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (app *App) handleCachePurgeCEP(w http.ResponseWriter, r *http.Request) {
+	cep := mux.Vars(r)["cep"]
+	if !isValidCEP(cep) {
+		writeJSON(w, http.StatusUnprocessableEntity, ErrorResponse{Message: "invalid zipcode"})
+		return
+	}
+	if app.cepCache != nil {
+		if err := app.cepCache.Delete(r.Context(), normalizeCEP(cep)); err != nil {
+			slog.ErrorContext(r.Context(), "Error purging CEP cache", "error", err)
+			writeJSON(w, http.StatusBadGateway, ErrorResponse{Message: "error purging cep cache"})
+			return
+		}
+	}
+	app.publishInvalidation(r.Context(), invalidation{Scope: invalidateCEP, Key: normalizeCEP(cep)})
+	slog.InfoContext(r.Context(), "Admin purged CEP from cache", "cep", normalizeCEP(cep))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleCachePurgeWeather accepts ?ibge= or ?city=&state=, mirroring how
+// weather entries are keyed.
+func (app *App) handleCachePurgeWeather(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	address := &Address{IBGE: query.Get("ibge"), City: query.Get("city"), State: query.Get("state")}
+	if address.IBGE == "" && (address.City == "" || address.State == "") {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Message: "ibge or city and state are required"})
+		return
+	}
+	key := weatherCacheKey(address)
+	if app.weatherCache != nil {
+		app.weatherCache.Delete(key)
+	}
+	if app.forecastCache != nil {
+		app.forecastCache.Delete(forecastCacheKey(key, forecastDays))
+	}
+	app.publishInvalidation(r.Context(), invalidation{Scope: invalidateWeather, Key: key})
+	slog.InfoContext(r.Context(), "Admin purged weather cache entry", "key", key)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (app *App) handleBreakers(w http.ResponseWriter, r *http.Request) {
+	states := []BreakerState{}
+	for _, breaker := range app.breakers {
+		states = append(states, breaker.Snapshot())
+	}
+	writeJSON(w, http.StatusOK, states)
+}
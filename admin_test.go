@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func (env *e2eEnv) do(t *testing.T, method, path, token string) int {
+	t.Helper()
+	req, err := http.NewRequest(method, env.server.URL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func cacheStats(t *testing.T, env *e2eEnv) AdminCacheStatsResponse {
+	t.Helper()
+	resp, body := env.get(t, "/admin/cache", map[string]string{"Authorization": "Bearer admin-secret"})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", resp.StatusCode, body)
+	}
+	var stats AdminCacheStatsResponse
+	if err := json.Unmarshal(body, &stats); err != nil {
+		t.Fatalf("Error parsing response: %v", err)
+	}
+	return stats
+}
+
+func TestAdminCache_Auth(t *testing.T) {
+	t.Run("Rotas desabilitadas sem token configurado", func(t *testing.T) {
+		env := newE2EEnv(t, nil)
+		if status := env.do(t, "GET", "/admin/cache", ""); status != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", status)
+		}
+	})
+
+	env := newE2EEnv(t, func(cfg *Config) { cfg.AdminToken = "admin-secret" })
+	tests := []struct {
+		name     string
+		token    string
+		expected int
+	}{
+		{"Sem token", "", http.StatusUnauthorized},
+		{"Token incorreto", "wrong", http.StatusUnauthorized},
+		{"Token correto", "admin-secret", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := env.do(t, "GET", "/admin/cache", tt.token); status != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, status)
+			}
+		})
+	}
+}
+
+func TestAdminCache_StatsAndPurge(t *testing.T) {
+	redisServer := miniredis.RunT(t)
+	env := newE2EEnv(t, func(cfg *Config) {
+		cfg.AdminToken = "admin-secret"
+		cfg.RedisAddr = redisServer.Addr()
+		cfg.CEPCacheTTL = time.Hour
+	})
+
+	env.get(t, "/weather/01310100", nil)
+	env.get(t, "/weather/01310100", nil)
+	env.get(t, "/weather/20040002", nil)
+
+	stats := cacheStats(t, env)
+	if stats.CEP == nil || stats.CEP.Entries != 2 || stats.CEP.Hits != 1 {
+		t.Errorf("Unexpected CEP cache stats: %+v", stats.CEP)
+	}
+	if stats.Weather == nil || stats.Weather.Entries != 2 || stats.Weather.Hits != 1 || stats.Weather.MemoryBytes == 0 {
+		t.Errorf("Unexpected weather cache stats: %+v", stats.Weather)
+	}
+
+	t.Run("Remove um CEP", func(t *testing.T) {
+		if status := env.do(t, "DELETE", "/admin/cache/cep/01310-100", "admin-secret"); status != http.StatusNoContent {
+			t.Fatalf("Expected status 204, got %d", status)
+		}
+		if entries := cacheStats(t, env).CEP.Entries; entries != 1 {
+			t.Errorf("Expected 1 CEP entry, got %d", entries)
+		}
+	})
+
+	t.Run("Remove uma cidade", func(t *testing.T) {
+		if status := env.do(t, "DELETE", "/admin/cache/weather?ibge=3550308", "admin-secret"); status != http.StatusNoContent {
+			t.Fatalf("Expected status 204, got %d", status)
+		}
+		if entries := cacheStats(t, env).Weather.Entries; entries != 1 {
+			t.Errorf("Expected 1 weather entry, got %d", entries)
+		}
+		if status := env.do(t, "DELETE", "/admin/cache/weather", "admin-secret"); status != http.StatusBadRequest {
+			t.Errorf("Expected status 400 without a city, got %d", status)
+		}
+	})
+
+	t.Run("Limpa todos os caches", func(t *testing.T) {
+		if status := env.do(t, "DELETE", "/admin/cache", "admin-secret"); status != http.StatusNoContent {
+			t.Fatalf("Expected status 204, got %d", status)
+		}
+		stats := cacheStats(t, env)
+		if stats.CEP.Entries != 0 || stats.Weather.Entries != 0 {
+			t.Errorf("Expected empty caches, got cep=%d weather=%d", stats.CEP.Entries, stats.Weather.Entries)
+		}
+	})
+}
@@ -0,0 +1,36 @@
+package main
+
+type UVAdvisory struct {
+	UVIndex    float64 `json:"uv_index"`
+	Category   string  `json:"category"`
+	Label      string  `json:"label"`
+	Protection string  `json:"protection"`
+}
+
+// uvCategory follows the WHO UV index bands.
+func uvCategory(uv float64, isDay bool) string {
+	switch {
+	case !isDay:
+		return "night"
+	case uv < 3:
+		return "low"
+	case uv < 6:
+		return "moderate"
+	case uv < 8:
+		return "high"
+	case uv < 11:
+		return "very_high"
+	default:
+		return "extreme"
+	}
+}
+
+func newUVAdvisory(observation *Observation, lang string) *UVAdvisory {
+	category := uvCategory(observation.UV, observation.IsDay)
+	return &UVAdvisory{
+		UVIndex:    observation.UV,
+		Category:   category,
+		Label:      phrase(lang, "uv."+category+".label"),
+		Protection: phrase(lang, "uv."+category+".protection"),
+	}
+}
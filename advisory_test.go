@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestUVCategory(t *testing.T) {
+	tests := []struct {
+		name     string
+		uv       float64
+		isDay    bool
+		expected string
+	}{
+		{"Noite", 0, false, "night"},
+		{"Baixo", 2.9, true, "low"},
+		{"Moderado", 3, true, "moderate"},
+		{"Alto", 7, true, "high"},
+		{"Muito alto", 10.5, true, "very_high"},
+		{"Extremo", 12, true, "extreme"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := uvCategory(tt.uv, tt.isDay); result != tt.expected {
+				t.Errorf("uvCategory(%.1f, %v) = %s, expected %s", tt.uv, tt.isDay, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRequestLanguage(t *testing.T) {
+	tests := []struct {
+		name           string
+		target         string
+		acceptLanguage string
+		expected       string
+	}{
+		{"Padrão", "/weather/01310100", "", langPortuguese},
+		{"Query string", "/weather/01310100?lang=es", "en-US", langSpanish},
+		{"Accept-Language", "/weather/01310100", "en-US,en;q=0.9", langEnglish},
+		{"Primeiro idioma suportado", "/weather/01310100", "fr-FR, es;q=0.8", langSpanish},
+		{"Idioma não suportado", "/weather/01310100?lang=de", "de-DE", langPortuguese},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.target, nil)
+			req.Header.Set("Accept-Language", tt.acceptLanguage)
+			if result := requestLanguage(req); result != tt.expected {
+				t.Errorf("requestLanguage() = %s, expected %s", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNewUVAdvisory(t *testing.T) {
+	advisory := newUVAdvisory(&Observation{UV: 9, IsDay: true}, langEnglish)
+
+	if advisory.Category != "very_high" {
+		t.Errorf("Expected category 'very_high', got '%s'", advisory.Category)
+	}
+	if advisory.Label != "Very high" {
+		t.Errorf("Expected label 'Very high', got '%s'", advisory.Label)
+	}
+	if advisory.Protection != phraseCatalog[langEnglish]["uv.very_high.protection"] {
+		t.Errorf("Unexpected protection text: %s", advisory.Protection)
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+const (
+	breakerClosed   = "closed"
+	breakerOpen     = "open"
+	breakerHalfOpen = "half-open"
+)
+
+// CircuitBreaker stops calling an upstream after threshold consecutive
+// failures. After cooldown a single probe call is let through: success
+// closes the breaker, failure reopens it. A nil breaker allows everything.
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	name      string
+	threshold int
+	cooldown  time.Duration
+	state     string
+	failures  int
+	openedAt  time.Time
+	probing   bool
+	now       func() time.Time
+}
+
+type BreakerState struct {
+	Name                string     `json:"name"`
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	OpenedAt            *time.Time `json:"opened_at,omitempty"`
+}
+
+func NewCircuitBreaker(name string, threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{name: name, threshold: threshold, cooldown: cooldown, state: breakerClosed, now: time.Now}
+}
+
+func (b *CircuitBreaker) Allow() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case breakerOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return ErrCircuitOpen
+		}
+		b.state = breakerHalfOpen
+		slog.Info("Circuit breaker half-open, probing upstream", "breaker", b.name)
+		fallthrough
+	case breakerHalfOpen:
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// Record reports the outcome of a call that Allow let through.
+func (b *CircuitBreaker) Record(failed bool) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if !failed {
+		if b.state != breakerClosed {
+			slog.Info("Circuit breaker closed", "breaker", b.name)
+		}
+		b.state = breakerClosed
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		if b.state != breakerOpen {
+			slog.Error("ALERT: circuit breaker open", "breaker", b.name, "consecutive_failures", b.failures)
+		}
+		b.state = breakerOpen
+		b.openedAt = b.now()
+	}
+}
+
+// Cancel releases a call that ended without a verdict, such as one whose
+// caller went away.
+func (b *CircuitBreaker) Cancel() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// call runs send through the breaker, counting network errors and 5xx
+// responses as failures.
+func (b *CircuitBreaker) call(ctx context.Context, send func() (*http.Response, error)) (*http.Response, error) {
+	if err := b.Allow(); err != nil {
+		return nil, err
+	}
+	resp, err := send()
+	if ctx.Err() != nil {
+		b.Cancel()
+	} else {
+		b.Record(err != nil || resp.StatusCode >= http.StatusInternalServerError)
+	}
+	return resp, err
+}
+
+func (b *CircuitBreaker) retryAfter() time.Duration {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state != breakerOpen {
+		return 0
+	}
+	return max(b.cooldown-b.now().Sub(b.openedAt), 0)
+}
+
+func (b *CircuitBreaker) Snapshot() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	state := BreakerState{Name: b.name, State: b.state, ConsecutiveFailures: b.failures}
+	if b.state != breakerClosed {
+		openedAt := b.openedAt
+		state.OpenedAt = &openedAt
+	}
+	return state
+}
+
+// breakerStateValues encodes the state as a gauge: 0 closed, 1 half-open,
+// 2 open.
+var breakerStateValues = map[string]float64{breakerClosed: 0, breakerHalfOpen: 1, breakerOpen: 2}
+
+// collector exports the breaker state, read at scrape time, so an open
+// breaker can be alerted on rather than only seen in /admin/breakers.
+func (b *CircuitBreaker) collector() prometheus.Collector {
+	return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name:        "projetodeploy_circuit_breaker_state",
+		Help:        "Circuit breaker state by upstream: 0 closed, 1 half-open, 2 open.",
+		ConstLabels: prometheus.Labels{"upstream": b.name},
+	}, func() float64 {
+		return breakerStateValues[b.Snapshot().State]
+	})
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Date(2024, 5, 17, 13, 0, 0, 0, time.UTC)
+	newBreaker := func() *CircuitBreaker {
+		breaker := NewCircuitBreaker("test", 3, 30*time.Second)
+		breaker.now = func() time.Time { return now }
+		return breaker
+	}
+	fail := func(b *CircuitBreaker, n int) {
+		for i := 0; i < n; i++ {
+			if err := b.Allow(); err == nil {
+				b.Record(true)
+			}
+		}
+	}
+
+	t.Run("Abre após falhas consecutivas", func(t *testing.T) {
+		breaker := newBreaker()
+		fail(breaker, 2)
+		breaker.Allow()
+		breaker.Record(false)
+		fail(breaker, 2)
+		if err := breaker.Allow(); err != nil {
+			t.Fatalf("Expected breaker closed after a success reset the count, got %v", err)
+		}
+		breaker.Record(true)
+		if err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) {
+			t.Errorf("Expected ErrCircuitOpen, got %v", err)
+		}
+		if state := breaker.Snapshot(); state.State != breakerOpen || state.ConsecutiveFailures != 3 {
+			t.Errorf("Unexpected state: %+v", state)
+		}
+	})
+
+	t.Run("Meio-aberto permite uma única sonda", func(t *testing.T) {
+		breaker := newBreaker()
+		fail(breaker, 3)
+		now = now.Add(30 * time.Second)
+
+		if err := breaker.Allow(); err != nil {
+			t.Fatalf("Expected a probe after the cooldown, got %v", err)
+		}
+		if err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) {
+			t.Errorf("Expected a second concurrent call to be rejected, got %v", err)
+		}
+		breaker.Record(false)
+		if state := breaker.Snapshot(); state.State != breakerClosed {
+			t.Errorf("Expected breaker closed after a successful probe, got %s", state.State)
+		}
+	})
+
+	t.Run("Sonda com falha reabre", func(t *testing.T) {
+		breaker := newBreaker()
+		fail(breaker, 3)
+		now = now.Add(30 * time.Second)
+		breaker.Allow()
+		breaker.Record(true)
+
+		if err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) {
+			t.Errorf("Expected breaker to reopen, got %v", err)
+		}
+		if retryAfter := breaker.retryAfter(); retryAfter != 30*time.Second {
+			t.Errorf("Expected a fresh 30s cooldown, got %s", retryAfter)
+		}
+	})
+}
+
+func TestE2E_CircuitBreaker(t *testing.T) {
+	env := newE2EEnv(t, func(cfg *Config) {
+		cfg.AdminToken = "admin-secret"
+		cfg.BreakerThreshold = 2
+		cfg.BreakerCooldown = time.Minute
+	})
+	env.weatherAPI.set(func(f *fakeWeatherAPI) { f.status = http.StatusBadGateway })
+
+	for i := 0; i < 2; i++ {
+		if resp, _ := env.get(t, "/weather/01310100", nil); resp.StatusCode != http.StatusInternalServerError {
+			t.Fatalf("Expected status 500 while the breaker is closed, got %d", resp.StatusCode)
+		}
+	}
+
+	resp, body := env.get(t, "/weather/01310100", nil)
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status 503 with the breaker open, got %d: %s", resp.StatusCode, body)
+	}
+	expectMessage(t, body, "weather provider unavailable")
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "60" {
+		t.Errorf("Expected Retry-After 60, got %q", retryAfter)
+	}
+	if calls := atomic.LoadInt32(&env.weatherAPI.calls); calls != 2 {
+		t.Errorf("Expected the open breaker to skip WeatherAPI, got %d calls", calls)
+	}
+
+	resp, body = env.get(t, "/admin/breakers", map[string]string{"Authorization": "Bearer admin-secret"})
+	var states []BreakerState
+	if err := json.Unmarshal(body, &states); err != nil {
+		t.Fatalf("Error parsing response: %v", err)
+	}
+	if len(states) != 2 || states[0].State != breakerClosed || states[1].State != breakerOpen {
+		t.Errorf("Unexpected breaker states: %+v", states)
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// deadlineBudget bounds a weather lookup end to end and reserves part of it
+// for the weather call: the CEP lookup may only use cepShare of the time
+// left, so a slow ViaCEP cannot starve WeatherAPI of its turn.
+type deadlineBudget struct {
+	total    time.Duration
+	cepShare float64
+}
+
+func (b deadlineBudget) start(ctx context.Context) (context.Context, context.CancelFunc) {
+	if b.total <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, b.total)
+}
+
+func (b deadlineBudget) cepContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok || b.cepShare <= 0 || b.cepShare >= 1 {
+		return context.WithCancel(ctx)
+	}
+	remaining := time.Until(deadline)
+	return context.WithTimeout(ctx, time.Duration(float64(remaining)*b.cepShare))
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDeadlineBudget(t *testing.T) {
+	t.Run("CEP recebe apenas sua fração do tempo restante", func(t *testing.T) {
+		budget := deadlineBudget{total: time.Second, cepShare: 0.4}
+		ctx, cancel := budget.start(context.Background())
+		defer cancel()
+		cepCtx, cancelCEP := budget.cepContext(ctx)
+		defer cancelCEP()
+
+		deadline, ok := cepCtx.Deadline()
+		if !ok {
+			t.Fatal("Expected CEP context to have a deadline")
+		}
+		if remaining := time.Until(deadline); remaining > 400*time.Millisecond || remaining < 350*time.Millisecond {
+			t.Errorf("Expected about 400ms for the CEP lookup, got %s", remaining)
+		}
+	})
+
+	t.Run("Sem orçamento não impõe prazo", func(t *testing.T) {
+		budget := deadlineBudget{}
+		ctx, cancel := budget.start(context.Background())
+		defer cancel()
+		cepCtx, cancelCEP := budget.cepContext(ctx)
+		defer cancelCEP()
+
+		if _, ok := cepCtx.Deadline(); ok {
+			t.Error("Expected no deadline without a budget")
+		}
+	})
+}
+
+func TestE2E_DeadlineBudget(t *testing.T) {
+	env := newE2EEnv(t, func(cfg *Config) {
+		cfg.RequestBudget = 300 * time.Millisecond
+		cfg.CEPBudgetShare = 0.4
+	})
+
+	t.Run("ViaCEP lenta não consome o tempo da WeatherAPI", func(t *testing.T) {
+		env.viaCEP.set(func(f *fakeViaCEP) { f.latency = 200 * time.Millisecond })
+		defer env.viaCEP.set(func(f *fakeViaCEP) { f.latency = 0 })
+
+		start := time.Now()
+		resp, body := env.get(t, "/weather/01310100", nil)
+		if resp.StatusCode != http.StatusGatewayTimeout {
+			t.Fatalf("Expected status 504, got %d: %s", resp.StatusCode, body)
+		}
+		expectMessage(t, body, "upstream timeout")
+		if elapsed := time.Since(start); elapsed >= 200*time.Millisecond {
+			t.Errorf("Expected the CEP share to cut the lookup short, took %s", elapsed)
+		}
+		if calls := atomic.LoadInt32(&env.weatherAPI.calls); calls != 0 {
+			t.Errorf("Expected no WeatherAPI call, got %d", calls)
+		}
+	})
+
+	t.Run("WeatherAPI usa o restante do orçamento", func(t *testing.T) {
+		env.weatherAPI.set(func(f *fakeWeatherAPI) { f.latency = 150 * time.Millisecond })
+
+		resp, body := env.get(t, "/weather/20040002", nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", resp.StatusCode, body)
+		}
+	})
+}
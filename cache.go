@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type CEPCache interface {
+	Get(ctx context.Context, cep string) (*Address, bool, error)
+	Set(ctx context.Context, cep string, address *Address) error
+	Delete(ctx context.Context, cep string) error
+	Flush(ctx context.Context) error
+	Stats(ctx context.Context) (CacheStats, error)
+	Entries(ctx context.Context) (map[string]*Address, error)
+}
+
+type CacheStats struct {
+	Backend     string       `json:"backend"`
+	Entries     int64        `json:"entries"`
+	Hits        uint64       `json:"hits"`
+	Misses      uint64       `json:"misses"`
+	HitRate     float64      `json:"hit_rate"`
+	MemoryBytes int64        `json:"memory_bytes"`
+	Tiers       []CacheStats `json:"tiers,omitempty"`
+}
+
+func newCacheStats(backend string, entries int64, hits, misses uint64, memoryBytes int64) CacheStats {
+	stats := CacheStats{Backend: backend, Entries: entries, Hits: hits, Misses: misses, MemoryBytes: memoryBytes}
+	if total := hits + misses; total > 0 {
+		stats.HitRate = float64(hits) / float64(total)
+	}
+	return stats
+}
+
+type RedisCEPCache struct {
+	client *redis.Client
+	ttl    time.Duration
+	jitter float64
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+func NewRedisCEPCache(client *redis.Client, ttl time.Duration) *RedisCEPCache {
+	return &RedisCEPCache{client: client, ttl: ttl}
+}
+
+func cepCacheKey(cep string) string {
+	return "cep:" + cep
+}
+
+func (c *RedisCEPCache) Get(ctx context.Context, cep string) (*Address, bool, error) {
+	data, err := c.client.Get(ctx, cepCacheKey(cep)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		c.misses.Add(1)
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	var address Address
+	if err := json.Unmarshal(data, &address); err != nil {
+		return nil, false, err
+	}
+	c.hits.Add(1)
+	return &address, true, nil
+}
+
+func (c *RedisCEPCache) Set(ctx context.Context, cep string, address *Address) error {
+	data, err := json.Marshal(address)
+	if err != nil {
+		return err
+	}
+	return c.client.Set(ctx, cepCacheKey(cep), data, jitteredTTL(c.ttl, c.jitter)).Err()
+}
+
+func (c *RedisCEPCache) Delete(ctx context.Context, cep string) error {
+	return c.client.Del(ctx, cepCacheKey(cep)).Err()
+}
+
+// Flush removes only our CEP keys; the Redis database may be shared.
+func (c *RedisCEPCache) Flush(ctx context.Context) error {
+	iter := c.client.Scan(ctx, 0, cepCacheKey("*"), 1000).Iterator()
+	for iter.Next(ctx) {
+		if err := c.client.Del(ctx, iter.Val()).Err(); err != nil {
+			return err
+		}
+	}
+	return iter.Err()
+}
+
+func (c *RedisCEPCache) Entries(ctx context.Context) (map[string]*Address, error) {
+	entries := make(map[string]*Address)
+	iter := c.client.Scan(ctx, 0, cepCacheKey("*"), 1000).Iterator()
+	for iter.Next(ctx) {
+		data, err := c.client.Get(ctx, iter.Val()).Bytes()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var address Address
+		if err := json.Unmarshal(data, &address); err != nil {
+			return nil, err
+		}
+		entries[strings.TrimPrefix(iter.Val(), cepCacheKey(""))] = &address
+	}
+	return entries, iter.Err()
+}
+
+func (c *RedisCEPCache) Stats(ctx context.Context) (CacheStats, error) {
+	var entries int64
+	iter := c.client.Scan(ctx, 0, cepCacheKey("*"), 1000).Iterator()
+	for iter.Next(ctx) {
+		entries++
+	}
+	if err := iter.Err(); err != nil {
+		return CacheStats{}, err
+	}
+	// Some managed Redis deployments restrict INFO; memory is then reported
+	// as unknown instead of failing the whole stats call.
+	var memoryBytes int64
+	if info, err := c.client.Info(ctx, "memory").Result(); err == nil {
+		memoryBytes = parseRedisUsedMemory(info)
+	}
+	return newCacheStats("redis", entries, c.hits.Load(), c.misses.Load(), memoryBytes), nil
+}
+
+// parseRedisUsedMemory extracts used_memory from INFO output. It covers the
+// whole Redis instance, not only our keys.
+func parseRedisUsedMemory(info string) int64 {
+	for _, line := range strings.Split(info, "\n") {
+		if value, ok := strings.CutPrefix(strings.TrimSpace(line), "used_memory:"); ok {
+			bytes, _ := strconv.ParseInt(value, 10, 64)
+			return bytes
+		}
+	}
+	return 0
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestRedisCEPCache(t *testing.T, ttl time.Duration) (*RedisCEPCache, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewRedisCEPCache(client, ttl), server
+}
+
+func TestRedisCEPCache(t *testing.T) {
+	cache, server := newTestRedisCEPCache(t, time.Hour)
+	ctx := context.Background()
+
+	if _, found, err := cache.Get(ctx, "01310100"); err != nil || found {
+		t.Fatalf("Expected cache miss, got found=%v err=%v", found, err)
+	}
+
+	if err := cache.Set(ctx, "01310100", &Address{CEP: "01310100", City: "São Paulo", State: "SP"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	address, found, err := cache.Get(ctx, "01310100")
+	if err != nil || !found {
+		t.Fatalf("Expected cache hit, got found=%v err=%v", found, err)
+	}
+	if address.City != "São Paulo" {
+		t.Errorf("Expected city 'São Paulo', got '%s'", address.City)
+	}
+
+	server.FastForward(time.Hour + time.Second)
+	if _, found, _ := cache.Get(ctx, "01310100"); found {
+		t.Error("Expected entry to expire after TTL")
+	}
+}
+
+func TestCEPService_GetCEPInfoCached(t *testing.T) {
+	cache, server := newTestRedisCEPCache(t, time.Hour)
+	mockClient := NewMockHTTPClient()
+	service := NewCEPService(mockClient)
+	service.cache = cache
+	cepURL := "https://viacep.com.br/ws/01310100/json/"
+
+	t.Run("Segunda consulta não chama a ViaCEP", func(t *testing.T) {
+		mockClient.AddResponse(cepURL, 200, `{"cep": "01310-100", "localidade": "São Paulo", "uf": "SP"}`)
+		if _, err := service.GetCEPInfo(context.Background(), "01310100"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		mockClient.AddError(cepURL, errors.New("connection error"))
+		result, err := service.GetCEPInfo(context.Background(), "01310100")
+		if err != nil {
+			t.Fatalf("Expected cached result, got %v", err)
+		}
+		if result.State != "SP" {
+			t.Errorf("Expected state 'SP', got '%s'", result.State)
+		}
+	})
+
+	t.Run("CEP não encontrado não é cacheado", func(t *testing.T) {
+		mockClient.AddResponse("https://viacep.com.br/ws/99999999/json/", 200, `{"erro": true}`)
+		if _, err := service.GetCEPInfo(context.Background(), "99999999"); !errors.Is(err, ErrCEPNotFound) {
+			t.Fatalf("Expected ErrCEPNotFound, got %v", err)
+		}
+		if server.Exists("cep:99999999") {
+			t.Error("Expected not-found CEP to stay out of the cache")
+		}
+	})
+
+	t.Run("Falha no Redis não derruba a consulta", func(t *testing.T) {
+		server.Close()
+		mockClient.AddResponse("https://viacep.com.br/ws/20040002/json/", 200, `{"cep": "20040-002", "localidade": "Rio de Janeiro", "uf": "RJ"}`)
+		result, err := service.GetCEPInfo(context.Background(), "20040002")
+		if err != nil {
+			t.Fatalf("Expected upstream result despite Redis failure, got %v", err)
+		}
+		if result.State != "RJ" {
+			t.Errorf("Expected state 'RJ', got '%s'", result.State)
+		}
+	})
+}
+
+func TestParseRedisUsedMemory(t *testing.T) {
+	info := "# Memory\r\nused_memory:1048576\r\nused_memory_human:1.00M\r\n"
+	if bytes := parseRedisUsedMemory(info); bytes != 1048576 {
+		t.Errorf("Expected 1048576 bytes, got %d", bytes)
+	}
+	if bytes := parseRedisUsedMemory("# Clients\r\nconnected_clients:1\r\n"); bytes != 0 {
+		t.Errorf("Expected 0 bytes without used_memory, got %d", bytes)
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+// phraseCatalog holds our own localized texts, independent of whatever
+// language the weather provider answers in. Keys are grouped by prefix:
+// condition.<enum>, uv.<category>.label and uv.<category>.protection.
+var phraseCatalog = map[string]map[string]string{
+	langPortuguese: {
+		"condition.clear":         "Céu limpo",
+		"condition.partly_cloudy": "Parcialmente nublado",
+		"condition.cloudy":        "Nublado",
+		"condition.overcast":      "Encoberto",
+		"condition.mist":          "Névoa",
+		"condition.fog":           "Neblina",
+		"condition.drizzle":       "Garoa",
+		"condition.light_rain":    "Chuva fraca",
+		"condition.rain":          "Chuva",
+		"condition.heavy_rain":    "Chuva forte",
+		"condition.freezing_rain": "Chuva congelante",
+		"condition.sleet":         "Chuva com granizo",
+		"condition.snow":          "Neve",
+		"condition.thunderstorm":  "Tempestade com trovoadas",
+		"condition.unknown":       "Condição desconhecida",
+
+		"uv.night.label":          "Noite",
+		"uv.low.label":            "Baixo",
+		"uv.moderate.label":       "Moderado",
+		"uv.high.label":           "Alto",
+		"uv.very_high.label":      "Muito alto",
+		"uv.extreme.label":        "Extremo",
+		"uv.night.protection":     "Sem radiação UV relevante à noite.",
+		"uv.low.protection":       "Nenhuma proteção especial necessária.",
+		"uv.moderate.protection":  "Use protetor solar, chapéu e óculos de sol; procure sombra perto do meio-dia.",
+		"uv.high.protection":      "Proteção obrigatória: protetor solar FPS 30+, chapéu, óculos e roupas com manga; reduza a exposição entre 10h e 16h.",
+		"uv.very_high.protection": "Proteção extra: evite atividades ao ar livre entre 10h e 16h e reaplique protetor solar a cada 2 horas.",
+		"uv.extreme.protection":   "Risco extremo: suspenda atividades ao ar livre não essenciais entre 10h e 16h.",
+	},
+	langEnglish: {
+		"condition.clear":         "Clear",
+		"condition.partly_cloudy": "Partly cloudy",
+		"condition.cloudy":        "Cloudy",
+		"condition.overcast":      "Overcast",
+		"condition.mist":          "Mist",
+		"condition.fog":           "Fog",
+		"condition.drizzle":       "Drizzle",
+		"condition.light_rain":    "Light rain",
+		"condition.rain":          "Rain",
+		"condition.heavy_rain":    "Heavy rain",
+		"condition.freezing_rain": "Freezing rain",
+		"condition.sleet":         "Sleet",
+		"condition.snow":          "Snow",
+		"condition.thunderstorm":  "Thunderstorm",
+		"condition.unknown":       "Unknown condition",
+
+		"uv.night.label":          "Night",
+		"uv.low.label":            "Low",
+		"uv.moderate.label":       "Moderate",
+		"uv.high.label":           "High",
+		"uv.very_high.label":      "Very high",
+		"uv.extreme.label":        "Extreme",
+		"uv.night.protection":     "No relevant UV exposure at night.",
+		"uv.low.protection":       "No special protection required.",
+		"uv.moderate.protection":  "Wear sunscreen, a hat and sunglasses; seek shade around midday.",
+		"uv.high.protection":      "Protection required: SPF 30+ sunscreen, hat, sunglasses and long sleeves; reduce exposure between 10am and 4pm.",
+		"uv.very_high.protection": "Extra protection: avoid outdoor work between 10am and 4pm and reapply sunscreen every 2 hours.",
+		"uv.extreme.protection":   "Extreme risk: suspend non-essential outdoor work between 10am and 4pm.",
+	},
+	langSpanish: {
+		"condition.clear":         "Despejado",
+		"condition.partly_cloudy": "Parcialmente nublado",
+		"condition.cloudy":        "Nublado",
+		"condition.overcast":      "Cubierto",
+		"condition.mist":          "Neblina",
+		"condition.fog":           "Niebla",
+		"condition.drizzle":       "Llovizna",
+		"condition.light_rain":    "Lluvia ligera",
+		"condition.rain":          "Lluvia",
+		"condition.heavy_rain":    "Lluvia fuerte",
+		"condition.freezing_rain": "Lluvia helada",
+		"condition.sleet":         "Aguanieve",
+		"condition.snow":          "Nieve",
+		"condition.thunderstorm":  "Tormenta eléctrica",
+		"condition.unknown":       "Condición desconocida",
+
+		"uv.night.label":          "Noche",
+		"uv.low.label":            "Bajo",
+		"uv.moderate.label":       "Moderado",
+		"uv.high.label":           "Alto",
+		"uv.very_high.label":      "Muy alto",
+		"uv.extreme.label":        "Extremo",
+		"uv.night.protection":     "Sin radiación UV relevante durante la noche.",
+		"uv.low.protection":       "No se requiere protección especial.",
+		"uv.moderate.protection":  "Use protector solar, sombrero y gafas de sol; busque sombra cerca del mediodía.",
+		"uv.high.protection":      "Protección obligatoria: protector solar FPS 30+, sombrero, gafas y ropa de manga larga; reduzca la exposición entre las 10 y las 16 h.",
+		"uv.very_high.protection": "Protección extra: evite actividades al aire libre entre las 10 y las 16 h y reaplique protector solar cada 2 horas.",
+		"uv.extreme.protection":   "Riesgo extremo: suspenda las actividades al aire libre no esenciales entre las 10 y las 16 h.",
+	},
+}
+
+// phrase looks a key up in the requested language, falling back to the
+// default language and finally to the key itself.
+func phrase(lang, key string) string {
+	if text, ok := phraseCatalog[lang][key]; ok {
+		return text
+	}
+	if text, ok := phraseCatalog[defaultLang][key]; ok {
+		return text
+	}
+	return key
+}
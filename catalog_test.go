@@ -0,0 +1,58 @@
+package main
+
+import "testing"
+
+func TestPhraseCatalogCompleteness(t *testing.T) {
+	for key := range phraseCatalog[defaultLang] {
+		for _, lang := range []string{langEnglish, langSpanish} {
+			if phraseCatalog[lang][key] == "" {
+				t.Errorf("Missing %s phrase for key %s", lang, key)
+			}
+		}
+	}
+	for _, condition := range weatherAPIConditions {
+		if _, ok := phraseCatalog[defaultLang]["condition."+condition]; !ok {
+			t.Errorf("Missing phrase for condition %s", condition)
+		}
+	}
+}
+
+func TestPhrase(t *testing.T) {
+	tests := []struct {
+		name     string
+		lang     string
+		key      string
+		expected string
+	}{
+		{"Português", langPortuguese, "condition.rain", "Chuva"},
+		{"Espanhol", langSpanish, "condition.rain", "Lluvia"},
+		{"Idioma desconhecido usa o padrão", "fr", "condition.rain", "Chuva"},
+		{"Chave desconhecida", langEnglish, "condition.hail", "condition.hail"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := phrase(tt.lang, tt.key); result != tt.expected {
+				t.Errorf("phrase(%s, %s) = %s, expected %s", tt.lang, tt.key, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestConditionFromWeatherAPICode(t *testing.T) {
+	tests := []struct {
+		code     int
+		expected string
+	}{
+		{1000, "clear"},
+		{1189, "rain"},
+		{1276, "thunderstorm"},
+		{9999, "unknown"},
+	}
+
+	for _, tt := range tests {
+		if result := conditionFromWeatherAPICode(tt.code); result != tt.expected {
+			t.Errorf("conditionFromWeatherAPICode(%d) = %s, expected %s", tt.code, result, tt.expected)
+		}
+	}
+}
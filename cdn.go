@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// CDNCachePolicy controls the caching headers emitted for shared caches
+// (Fastly, CloudFront) sitting in front of the service.
+type CDNCachePolicy struct {
+	MaxAge  time.Duration
+	SMaxAge time.Duration
+}
+
+func (p CDNCachePolicy) enabled() bool {
+	return p.MaxAge > 0 || p.SMaxAge > 0
+}
+
+type cacheHeadersWriter struct {
+	http.ResponseWriter
+	policy      CDNCachePolicy
+	wroteHeader bool
+}
+
+func (w *cacheHeadersWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.setHeaders(status)
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *cacheHeadersWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *cacheHeadersWriter) setHeaders(status int) {
+	header := w.Header()
+	// The body depends on the negotiated format and language, so shared
+	// caches must key on them to avoid serving one variant for another.
+	header.Add("Vary", "Accept")
+	header.Add("Vary", "Accept-Language")
+	if status != http.StatusOK {
+		header.Set("Cache-Control", "no-store")
+		return
+	}
+	maxAge := int(w.policy.MaxAge.Seconds())
+	sMaxAge := int(w.policy.SMaxAge.Seconds())
+	if sMaxAge == 0 {
+		sMaxAge = maxAge
+	}
+	header.Set("Cache-Control", fmt.Sprintf("public, max-age=%d, s-maxage=%d", maxAge, sMaxAge))
+	header.Set("Surrogate-Control", fmt.Sprintf("max-age=%d", sMaxAge))
+}
+
+func (app *App) cacheHeadersMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Only GETs are cacheable; POST bodies must never be shared.
+		if !app.cdnPolicy.enabled() || r.Method != http.MethodGet {
+			next.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(&cacheHeadersWriter{ResponseWriter: w, policy: app.cdnPolicy}, r)
+	})
+}
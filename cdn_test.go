@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCacheHeadersMiddleware(t *testing.T) {
+	okHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, TemperatureResponse{TempC: 25})
+	})
+	errorHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusNotFound, ErrorResponse{Message: "can not find zipcode"})
+	})
+
+	t.Run("Desabilitado por padrão", func(t *testing.T) {
+		app := &App{}
+		rr := httptest.NewRecorder()
+		app.cacheHeadersMiddleware(okHandler).ServeHTTP(rr, httptest.NewRequest("GET", "/weather/01310100", nil))
+
+		if rr.Header().Get("Cache-Control") != "" || rr.Header().Get("Vary") != "" {
+			t.Errorf("Expected no caching headers, got %v", rr.Header())
+		}
+	})
+
+	t.Run("Resposta de sucesso cacheável", func(t *testing.T) {
+		app := &App{cdnPolicy: CDNCachePolicy{MaxAge: time.Minute, SMaxAge: 5 * time.Minute}}
+		rr := httptest.NewRecorder()
+		app.cacheHeadersMiddleware(okHandler).ServeHTTP(rr, httptest.NewRequest("GET", "/weather/01310100", nil))
+
+		if cacheControl := rr.Header().Get("Cache-Control"); cacheControl != "public, max-age=60, s-maxage=300" {
+			t.Errorf("Unexpected Cache-Control: %s", cacheControl)
+		}
+		if surrogate := rr.Header().Get("Surrogate-Control"); surrogate != "max-age=300" {
+			t.Errorf("Unexpected Surrogate-Control: %s", surrogate)
+		}
+		vary := rr.Header().Values("Vary")
+		if len(vary) != 2 || vary[0] != "Accept" || vary[1] != "Accept-Language" {
+			t.Errorf("Unexpected Vary: %v", vary)
+		}
+	})
+
+	t.Run("Erros não são cacheados", func(t *testing.T) {
+		app := &App{cdnPolicy: CDNCachePolicy{MaxAge: time.Minute}}
+		rr := httptest.NewRecorder()
+		app.cacheHeadersMiddleware(errorHandler).ServeHTTP(rr, httptest.NewRequest("GET", "/weather/99999999", nil))
+
+		if cacheControl := rr.Header().Get("Cache-Control"); cacheControl != "no-store" {
+			t.Errorf("Expected Cache-Control no-store, got %s", cacheControl)
+		}
+		if rr.Header().Get("Surrogate-Control") != "" {
+			t.Error("Expected no Surrogate-Control on errors")
+		}
+	})
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+const (
+	defaultBrasilAPIBaseURL  = "https://brasilapi.com.br"
+	defaultAwesomeAPIBaseURL = "https://cep.awesomeapi.com.br"
+)
+
+// CEPResolver is an alternate source of addresses, tried in order when
+// ViaCEP fails with anything other than "not found".
+type CEPResolver interface {
+	Name() string
+	Resolve(ctx context.Context, cep string) (*Address, error)
+}
+
+type brasilAPIResponse struct {
+	CEP          string `json:"cep"`
+	State        string `json:"state"`
+	City         string `json:"city"`
+	Neighborhood string `json:"neighborhood"`
+	Street       string `json:"street"`
+}
+
+type awesomeAPIResponse struct {
+	CEP      string `json:"cep"`
+	Address  string `json:"address"`
+	State    string `json:"state"`
+	District string `json:"district"`
+	City     string `json:"city"`
+	CityIBGE string `json:"city_ibge"`
+	DDD      string `json:"ddd"`
+}
+
+// httpCEPResolver covers providers that answer GET {base}/.../{cep} with a
+// JSON address and 404 for unknown CEPs.
+type httpCEPResolver struct {
+	name       string
+	httpClient HTTPClient
+	baseURL    string
+	pathFormat string
+	decode     func(resp *http.Response) (*Address, error)
+	breaker    *CircuitBreaker
+}
+
+func newBrasilAPIResolver(client HTTPClient, baseURL string) *httpCEPResolver {
+	return &httpCEPResolver{
+		name:       "brasilapi",
+		httpClient: client,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		pathFormat: "/api/cep/v1/%s",
+		decode: func(resp *http.Response) (*Address, error) {
+			var r brasilAPIResponse
+			if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+				return nil, err
+			}
+			return &Address{CEP: normalizeCEP(r.CEP), Street: r.Street, Neighborhood: r.Neighborhood, City: r.City, State: r.State}, nil
+		},
+	}
+}
+
+func newAwesomeAPIResolver(client HTTPClient, baseURL string) *httpCEPResolver {
+	return &httpCEPResolver{
+		name:       "awesomeapi",
+		httpClient: client,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		pathFormat: "/json/%s",
+		decode: func(resp *http.Response) (*Address, error) {
+			var r awesomeAPIResponse
+			if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+				return nil, err
+			}
+			return &Address{CEP: normalizeCEP(r.CEP), Street: r.Address, Neighborhood: r.District, City: r.City, State: r.State, IBGE: r.CityIBGE, DDD: r.DDD}, nil
+		},
+	}
+}
+
+func (r *httpCEPResolver) Name() string {
+	return r.name
+}
+
+func (r *httpCEPResolver) Resolve(ctx context.Context, cep string) (*Address, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.baseURL+fmt.Sprintf(r.pathFormat, cep), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.breaker.call(ctx, func() (*http.Response, error) {
+		return r.httpClient.Do(req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrCEPNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s error: %d", r.name, resp.StatusCode)
+	}
+	return r.decode(resp)
+}
+
+func newCEPResolver(name string, client HTTPClient, cfg Config) (*httpCEPResolver, error) {
+	switch name {
+	case "brasilapi":
+		return newBrasilAPIResolver(client, cfg.BrasilAPIBaseURL), nil
+	case "awesomeapi":
+		return newAwesomeAPIResolver(client, cfg.AwesomeAPIBaseURL), nil
+	}
+	return nil, fmt.Errorf("unknown CEP provider %q", name)
+}
+
+// cepLookupSettled reports whether a provider's answer is final: an address
+// or a definitive "not found".
+func cepLookupSettled(err error) bool {
+	return err == nil || errors.Is(err, ErrCEPNotFound)
+}
+
+// resolve asks ViaCEP and walks the fallback chain only when ViaCEP could
+// not answer; a "not found" from any provider is final.
+func (s *CEPService) resolve(ctx context.Context, cep string) (*Address, error) {
+	fallbacks := s.fallbacks
+	var address *Address
+	var err error
+	if s.hedgeAfter > 0 && len(fallbacks) > 0 {
+		// A slow ViaCEP races the first fallback; if that one already ran
+		// it is not tried again below.
+		var hedged bool
+		address, hedged, err = hedge(ctx, "viacep", s.hedgeAfter, cepLookupSettled,
+			func(ctx context.Context) (*Address, error) { return s.fetchCEPInfo(ctx, cep) },
+			func(ctx context.Context) (*Address, error) { return fallbacks[0].Resolve(ctx, cep) })
+		if hedged {
+			fallbacks = fallbacks[1:]
+		}
+	} else {
+		address, err = s.fetchCEPInfo(ctx, cep)
+	}
+	if cepLookupSettled(err) || ctx.Err() != nil {
+		return address, err
+	}
+	for _, fallback := range fallbacks {
+		slog.WarnContext(ctx, "ViaCEP lookup failed, trying fallback", "error", err, "fallback", fallback.Name())
+		address, fallbackErr := fallback.Resolve(ctx, cep)
+		if fallbackErr == nil || errors.Is(fallbackErr, ErrCEPNotFound) || ctx.Err() != nil {
+			return address, fallbackErr
+		}
+		slog.WarnContext(ctx, "CEP fallback failed", "fallback", fallback.Name(), "error", fallbackErr)
+	}
+	return nil, err
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestCEPService_FallbackChain(t *testing.T) {
+	viaCEPURL := "https://viacep.com.br/ws/01310100/json/"
+	brasilAPIURL := "https://brasilapi.local/api/cep/v1/01310100"
+	awesomeAPIURL := "https://awesomeapi.local/json/01310100"
+	brasilAPIBody := `{"cep": "01310100", "state": "SP", "city": "São Paulo", "neighborhood": "Bela Vista", "street": "Avenida Paulista"}`
+	awesomeAPIBody := `{"cep": "01310100", "address": "Avenida Paulista", "state": "SP", "district": "Bela Vista", "city": "São Paulo", "city_ibge": "3550308", "ddd": "11"}`
+
+	tests := []struct {
+		name          string
+		setup         func(m *MockHTTPClient)
+		expectedErr   error
+		expectedState string
+		expectedIBGE  string
+	}{
+		{"ViaCEP responde", func(m *MockHTTPClient) {
+			m.AddResponse(viaCEPURL, 200, `{"cep": "01310-100", "localidade": "São Paulo", "uf": "SP", "ibge": "3550308"}`)
+			m.AddError(brasilAPIURL, errors.New("should not be called"))
+		}, nil, "SP", "3550308"},
+		{"ViaCEP fora do ar usa a BrasilAPI", func(m *MockHTTPClient) {
+			m.AddError(viaCEPURL, errors.New("connection refused"))
+			m.AddResponse(brasilAPIURL, 200, brasilAPIBody)
+		}, nil, "SP", ""},
+		{"BrasilAPI também fora do ar usa a AwesomeAPI", func(m *MockHTTPClient) {
+			m.AddError(viaCEPURL, errors.New("connection refused"))
+			m.AddResponse(brasilAPIURL, 503, ``)
+			m.AddResponse(awesomeAPIURL, 200, awesomeAPIBody)
+		}, nil, "SP", "3550308"},
+		{"CEP inexistente na ViaCEP não consulta alternativas", func(m *MockHTTPClient) {
+			m.AddResponse(viaCEPURL, 200, `{"erro": true}`)
+			m.AddResponse(brasilAPIURL, 200, brasilAPIBody)
+		}, ErrCEPNotFound, "", ""},
+		{"CEP inexistente na alternativa", func(m *MockHTTPClient) {
+			m.AddError(viaCEPURL, errors.New("connection refused"))
+			m.AddResponse(brasilAPIURL, 404, `{"name": "CepPromiseError"}`)
+		}, ErrCEPNotFound, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := NewMockHTTPClient()
+			tt.setup(mockClient)
+			service := NewCEPService(mockClient)
+			service.fallbacks = []CEPResolver{
+				newBrasilAPIResolver(mockClient, "https://brasilapi.local/"),
+				newAwesomeAPIResolver(mockClient, "https://awesomeapi.local"),
+			}
+
+			result, err := service.GetCEPInfo(context.Background(), "01310100")
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Fatalf("Expected %v, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if result.State != tt.expectedState || result.IBGE != tt.expectedIBGE {
+				t.Errorf("Expected state %q and IBGE %q, got %+v", tt.expectedState, tt.expectedIBGE, result)
+			}
+		})
+	}
+
+	t.Run("Todos os provedores fora do ar", func(t *testing.T) {
+		mockClient := NewMockHTTPClient()
+		mockClient.AddError(viaCEPURL, errors.New("connection refused"))
+		mockClient.AddResponse(brasilAPIURL, 502, ``)
+		service := NewCEPService(mockClient)
+		service.fallbacks = []CEPResolver{newBrasilAPIResolver(mockClient, "https://brasilapi.local")}
+
+		if _, err := service.GetCEPInfo(context.Background(), "01310100"); err == nil || err.Error() != "connection refused" {
+			t.Errorf("Expected ViaCEP's error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const checkProbeCEP = "01001000"
+
+var checkProbeLocation = weatherLocation{City: "São Paulo", State: "SP"}
+
+type checkRow struct {
+	component string
+	setting   string
+	value     string
+	status    string
+}
+
+// checkReport collects what the app would run with; any failed row makes the
+// check exit non-zero.
+type checkReport struct {
+	rows   []checkRow
+	failed bool
+}
+
+func (r *checkReport) ok(component, setting, value string) {
+	r.rows = append(r.rows, checkRow{component, setting, value, "ok"})
+}
+
+func (r *checkReport) fail(component, setting, value string, err error) {
+	r.rows = append(r.rows, checkRow{component, setting, value, "FAIL: " + err.Error()})
+	r.failed = true
+}
+
+func (r *checkReport) print(out io.Writer) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "COMPONENT\tSETTING\tVALUE\tSTATUS")
+	for _, row := range r.rows {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", row.component, row.setting, row.value, row.status)
+	}
+	w.Flush()
+}
+
+// runCheck implements `projetodeploy check`: it validates the configuration
+// and, with -probe, performs one real lookup against every configured
+// upstream. It returns the process exit code.
+func runCheck(args []string, out io.Writer) int {
+	flags := flag.NewFlagSet("check", flag.ContinueOnError)
+	flags.SetOutput(out)
+	probe := flags.Bool("probe", false, "query each configured upstream")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	report := &checkReport{}
+	cfg, err := loadConfig()
+	switch {
+	case err != nil:
+		report.fail("config", "environment", "-", err)
+	case cfg.WeatherProvider == weatherProviderOpenMeteo:
+		report.ok("weather", "WEATHER_PROVIDER", cfg.WeatherProvider)
+	default:
+		report.ok("weatherapi", "WEATHER_API_KEY", maskSecret(cfg.WeatherAPIKey))
+	}
+	checkConfig(report, cfg)
+	if *probe {
+		probeUpstreams(report, cfg, newHTTPClient(cfg))
+	}
+
+	report.print(out)
+	if report.failed {
+		fmt.Fprintln(out, "check failed")
+		return 1
+	}
+	fmt.Fprintln(out, "check passed")
+	return 0
+}
+
+func checkConfig(report *checkReport, cfg Config) {
+	if cfg.ListenAddr != "" && cfg.ListenAddr != ":"+cfg.Port {
+		checkListenAddr(report, "LISTEN_ADDR", cfg.ListenAddr)
+	} else if _, err := strconv.Atoi(cfg.Port); err != nil {
+		report.fail("server", "PORT", cfg.Port, fmt.Errorf("invalid port"))
+	} else {
+		report.ok("server", "PORT", cfg.Port)
+	}
+	if cfg.InternalAddr != "" {
+		checkListenAddr(report, "INTERNAL_ADDR", cfg.InternalAddr)
+	}
+	if _, err := newLogger(io.Discard, cfg.LogLevel, cfg.LogFormat); err != nil {
+		report.fail("logging", "LOG_LEVEL/LOG_FORMAT", cfg.LogLevel+"/"+cfg.LogFormat, err)
+	} else {
+		report.ok("logging", "LOG_LEVEL/LOG_FORMAT", cfg.LogLevel+"/"+cfg.LogFormat)
+	}
+	checkBaseURLs(report, "viacep", "VIACEP_BASE_URLS", cfg.ViaCEPBaseURLs)
+	if cfg.WeatherProvider == weatherProviderOpenMeteo {
+		checkBaseURLs(report, "open-meteo", "OPEN_METEO_GEOCODING_URL", []string{cfg.OpenMeteoGeocodingURL})
+		checkBaseURLs(report, "open-meteo", "OPEN_METEO_FORECAST_URL", []string{cfg.OpenMeteoForecastURL})
+	} else {
+		checkBaseURLs(report, "weatherapi", "WEATHERAPI_BASE_URLS", cfg.WeatherAPIBaseURLs)
+	}
+	for _, name := range cfg.CEPFallbackProviders {
+		if _, err := newCEPResolver(name, nil, cfg); err != nil {
+			report.fail("cep-fallback", "CEP_FALLBACK_PROVIDERS", name, err)
+		} else {
+			report.ok("cep-fallback", "CEP_FALLBACK_PROVIDERS", name)
+		}
+	}
+	checkPositive(report, "server", "SHUTDOWN_TIMEOUT", cfg.ShutdownTimeout)
+	checkPositive(report, "server", "SERVER_READ_HEADER_TIMEOUT", cfg.Server.ReadHeaderTimeout)
+	if w := cfg.Server.WriteTimeout; w > 0 && (w <= cfg.RequestBudget || w <= validateTimeout) {
+		report.fail("server", "SERVER_WRITE_TIMEOUT", w.String(), fmt.Errorf("must exceed REQUEST_BUDGET and %s", validateTimeout))
+	} else {
+		report.ok("server", "SERVER_WRITE_TIMEOUT", w.String())
+	}
+	checkPositive(report, "endpoints", "ENDPOINT_HEALTH_INTERVAL", cfg.EndpointHealthInterval)
+	checkPositive(report, "budget", "REQUEST_BUDGET", cfg.RequestBudget)
+	checkPositive(report, "http-client", "HTTP_CLIENT_TIMEOUT", cfg.HTTPClientTimeout)
+	checkPositive(report, "http-client", "DIAL_TIMEOUT", cfg.DialTimeout)
+	checkRetryPolicy(report, "viacep", "VIACEP_RETRY_MAX_ATTEMPTS", cfg.ViaCEPRetry)
+	checkRetryPolicy(report, "weatherapi", "WEATHERAPI_RETRY_MAX_ATTEMPTS", cfg.WeatherAPIRetry)
+	if cfg.BreakerThreshold > 0 {
+		checkPositive(report, "breakers", "CIRCUIT_BREAKER_COOLDOWN", cfg.BreakerCooldown)
+	}
+	if cfg.CEPBudgetShare <= 0 || cfg.CEPBudgetShare >= 1 {
+		report.fail("budget", "CEP_BUDGET_SHARE", strconv.FormatFloat(cfg.CEPBudgetShare, 'f', -1, 64), fmt.Errorf("must be between 0 and 1"))
+	} else {
+		report.ok("budget", "CEP_BUDGET_SHARE", strconv.FormatFloat(cfg.CEPBudgetShare, 'f', -1, 64))
+	}
+	radius := strconv.FormatFloat(cfg.CoordinateRadiusKm, 'f', -1, 64)
+	if cfg.CoordinateRadiusKm <= 0 {
+		report.fail("coordinates", "COORDINATE_OVERRIDE_RADIUS_KM", radius, fmt.Errorf("must be positive"))
+	} else {
+		report.ok("coordinates", "COORDINATE_OVERRIDE_RADIUS_KM", radius)
+	}
+	report.ok("access-log", "ACCESS_LOG", cfg.AccessLog)
+	switch {
+	case cfg.TLS.CertFile != "":
+		if _, _, err := newTLSConfig(cfg.TLS); err != nil {
+			report.fail("tls", "TLS_CERT_FILE", cfg.TLS.CertFile, err)
+		} else {
+			report.ok("tls", "TLS_CERT_FILE", cfg.TLS.CertFile)
+		}
+	case len(cfg.TLS.AutocertDomains) > 0:
+		report.ok("tls", "TLS_AUTOCERT_DOMAINS", strings.Join(cfg.TLS.AutocertDomains, ","))
+	}
+	if cfg.Energy.LoadPerDegreeDay < 0 {
+		report.fail("energy", "ENERGY_LOAD_PER_DEGREE_DAY", strconv.FormatFloat(cfg.Energy.LoadPerDegreeDay, 'f', -1, 64), fmt.Errorf("must not be negative"))
+	}
+	if cfg.RiskProfiles != nil {
+		report.ok("risk", "RISK_PROFILES_FILE", fmt.Sprintf("%d profiles", len(cfg.RiskProfiles)))
+	}
+	if cfg.SuggestionRules != nil {
+		report.ok("suggestions", "SUGGESTION_RULES_FILE", fmt.Sprintf("%d rules", len(cfg.SuggestionRules)))
+	}
+	if cfg.SLO.LatencyTarget > 0 {
+		report.ok("slo", "SLO_LATENCY_TARGET", cfg.SLO.LatencyTarget.String())
+		objective := strconv.FormatFloat(cfg.SLO.Objective, 'f', -1, 64)
+		if cfg.SLO.Objective <= 0 || cfg.SLO.Objective >= 1 {
+			report.fail("slo", "SLO_OBJECTIVE", objective, fmt.Errorf("must be between 0 and 1"))
+		} else {
+			report.ok("slo", "SLO_OBJECTIVE", objective)
+		}
+		if cfg.SLO.Window < time.Minute {
+			report.fail("slo", "SLO_WINDOW", cfg.SLO.Window.String(), fmt.Errorf("must be at least 1m"))
+		} else {
+			report.ok("slo", "SLO_WINDOW", cfg.SLO.Window.String())
+		}
+	}
+	if len(cfg.TrustedProxies) > 0 {
+		report.ok("client-ip", "TRUSTED_PROXIES", fmt.Sprintf("%d ranges", len(cfg.TrustedProxies)))
+	}
+	if cfg.Abuse.Window > 0 {
+		report.ok("abuse", "ABUSE_WINDOW", cfg.Abuse.Window.String())
+		if cfg.Abuse.MaxErrorRatio <= 0 || cfg.Abuse.MaxErrorRatio > 1 {
+			report.fail("abuse", "ABUSE_MAX_ERROR_RATIO", strconv.FormatFloat(cfg.Abuse.MaxErrorRatio, 'f', -1, 64), fmt.Errorf("must be between 0 and 1"))
+		}
+		if cfg.Abuse.MinRequests < 1 {
+			report.fail("abuse", "ABUSE_MIN_REQUESTS", strconv.Itoa(cfg.Abuse.MinRequests), fmt.Errorf("must be at least 1"))
+		}
+	}
+	if cfg.RedisAddr == "" {
+		report.ok("cep-cache", "REDIS_ADDR", "(disabled)")
+	} else {
+		report.ok("cep-cache", "REDIS_ADDR", cfg.RedisAddr)
+		checkPositive(report, "cep-cache", "CEP_CACHE_TTL", cfg.CEPCacheTTL)
+		if cfg.CEPCacheL1Size > 0 {
+			report.ok("cep-cache", "CEP_CACHE_L1_SIZE", strconv.Itoa(cfg.CEPCacheL1Size))
+			checkPositive(report, "cep-cache", "CEP_CACHE_L1_TTL", cfg.CEPCacheL1TTL)
+		}
+	}
+	if cfg.WeatherCacheSize <= 0 {
+		report.ok("weather-cache", "WEATHER_CACHE_SIZE", "(disabled)")
+	} else {
+		report.ok("weather-cache", "WEATHER_CACHE_SIZE", strconv.Itoa(cfg.WeatherCacheSize))
+		checkPositive(report, "weather-cache", "WEATHER_CACHE_TTL", cfg.WeatherCacheTTL)
+	}
+	for _, cep := range cfg.WarmupCEPs {
+		if !isValidCEP(cep) {
+			report.fail("warmup", "WARMUP_CEPS", cep, fmt.Errorf("invalid zipcode"))
+		} else {
+			report.ok("warmup", "WARMUP_CEPS", cep)
+		}
+	}
+	if cfg.CacheTTLJitter < 0 || cfg.CacheTTLJitter >= 1 {
+		report.fail("cache", "CACHE_TTL_JITTER", strconv.FormatFloat(cfg.CacheTTLJitter, 'f', -1, 64), fmt.Errorf("must be between 0 and 1"))
+	}
+	if cfg.AdminToken != "" {
+		report.ok("admin", "ADMIN_TOKEN", maskSecret(cfg.AdminToken))
+	}
+}
+
+func checkListenAddr(report *checkReport, setting, addr string) {
+	if path, ok := strings.CutPrefix(addr, unixSocketPrefix); ok {
+		if path == "" {
+			report.fail("server", setting, addr, fmt.Errorf("missing socket path"))
+			return
+		}
+		report.ok("server", setting, addr)
+		return
+	}
+	if _, port, err := net.SplitHostPort(addr); err != nil {
+		report.fail("server", setting, addr, err)
+	} else if _, err := strconv.Atoi(port); err != nil {
+		report.fail("server", setting, addr, fmt.Errorf("invalid port"))
+	} else {
+		report.ok("server", setting, addr)
+	}
+}
+
+func checkBaseURLs(report *checkReport, component, setting string, urls []string) {
+	for _, raw := range urls {
+		u, err := url.Parse(raw)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			report.fail(component, setting, raw, fmt.Errorf("invalid base URL"))
+			continue
+		}
+		report.ok(component, setting, raw)
+	}
+}
+
+func checkRetryPolicy(report *checkReport, component, setting string, policy RetryPolicy) {
+	if policy.MaxAttempts < 1 {
+		report.fail(component, setting, strconv.Itoa(policy.MaxAttempts), fmt.Errorf("must be at least 1"))
+		return
+	}
+	report.ok(component, setting, strconv.Itoa(policy.MaxAttempts))
+}
+
+func checkPositive(report *checkReport, component, setting string, d time.Duration) {
+	if d <= 0 {
+		report.fail(component, setting, d.String(), fmt.Errorf("must be positive"))
+		return
+	}
+	report.ok(component, setting, d.String())
+}
+
+// probeUpstreams runs the same lookups the handlers do, once per configured
+// base URL, so a bad mirror or key is caught before traffic reaches it.
+func probeUpstreams(report *checkReport, cfg Config, client HTTPClient) {
+	for _, baseURL := range cfg.ViaCEPBaseURLs {
+		if _, err := NewCEPService(client, baseURL).fetchCEPInfo(context.Background(), checkProbeCEP); err != nil {
+			report.fail("viacep", "probe", baseURL, redactURLError(err))
+		} else {
+			report.ok("viacep", "probe", baseURL)
+		}
+	}
+	for _, name := range cfg.CEPFallbackProviders {
+		resolver, err := newCEPResolver(name, client, cfg)
+		if err != nil {
+			continue
+		}
+		if _, err := resolver.Resolve(context.Background(), checkProbeCEP); err != nil {
+			report.fail("cep-fallback", "probe", resolver.baseURL, redactURLError(err))
+		} else {
+			report.ok("cep-fallback", "probe", resolver.baseURL)
+		}
+	}
+	if cfg.WeatherProvider == weatherProviderOpenMeteo {
+		source := NewOpenMeteoSource(client, cfg.OpenMeteoGeocodingURL, cfg.OpenMeteoForecastURL)
+		if _, err := source.Fetch(context.Background(), checkProbeLocation); err != nil {
+			report.fail("open-meteo", "probe", source.forecastURL, redactURLError(err))
+		} else {
+			report.ok("open-meteo", "probe", source.forecastURL)
+		}
+	} else if cfg.WeatherAPIKey != "" {
+		for _, baseURL := range cfg.WeatherAPIBaseURLs {
+			if _, err := NewWeatherService(client, cfg.WeatherAPIKey, baseURL).fetch(context.Background(), checkProbeLocation); err != nil {
+				report.fail("weatherapi", "probe", baseURL, redactURLError(err))
+			} else {
+				report.ok("weatherapi", "probe", baseURL)
+			}
+		}
+	}
+	if cfg.RedisAddr != "" {
+		redisClient := redis.NewClient(&redis.Options{Addr: cfg.RedisAddr, Password: cfg.RedisPassword, DB: cfg.RedisDB})
+		defer redisClient.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := redisClient.Ping(ctx).Err(); err != nil {
+			report.fail("cep-cache", "probe", cfg.RedisAddr, err)
+		} else {
+			report.ok("cep-cache", "probe", cfg.RedisAddr)
+		}
+	}
+}
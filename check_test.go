@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRunCheck(t *testing.T) {
+	viaCEP := newFakeViaCEP(t)
+	viaCEP.set(func(f *fakeViaCEP) {
+		f.addresses["01001000"] = ViaCEPResponse{CEP: "01001-000", Localidade: "São Paulo", UF: "SP", IBGE: "3550308"}
+	})
+	weatherAPI := newFakeWeatherAPI(t, "check-key")
+
+	tests := []struct {
+		name         string
+		env          map[string]string
+		args         []string
+		expectedCode int
+		expectedRow  string
+	}{
+		{"Configuração válida", map[string]string{"WEATHER_API_KEY": "check-key"}, nil, 0, "WEATHER_API_KEY"},
+		{"Sem chave da WeatherAPI", map[string]string{"WEATHER_API_KEY": ""}, nil, 1, "FAIL: WEATHER_API_KEY environment variable is required"},
+		{"URL base inválida", map[string]string{"WEATHER_API_KEY": "check-key", "VIACEP_BASE_URLS": "viacep.com.br"}, nil, 1, "FAIL: invalid base URL"},
+		{"Open-Meteo dispensa chave", map[string]string{"WEATHER_API_KEY": "", "WEATHER_PROVIDER": "open-meteo"}, nil, 0, "open-meteo"},
+		{"Provedor de clima desconhecido", map[string]string{"WEATHER_API_KEY": "check-key", "WEATHER_PROVIDER": "foo"}, nil, 1, `FAIL: unknown WEATHER_PROVIDER "foo"`},
+		{"Formato de access log desconhecido", map[string]string{"WEATHER_API_KEY": "check-key", "ACCESS_LOG": "xml"}, nil, 1, `FAIL: unknown ACCESS_LOG "xml"`},
+		{"Regras de sugestão ausentes", map[string]string{"WEATHER_API_KEY": "check-key", "SUGGESTION_RULES_FILE": "/nonexistent/rules.json"}, nil, 1, "FAIL: SUGGESTION_RULES_FILE"},
+		{"Write timeout menor que o orçamento", map[string]string{"WEATHER_API_KEY": "check-key", "SERVER_WRITE_TIMEOUT": "5s"}, nil, 1, "FAIL: must exceed REQUEST_BUDGET"},
+		{"Certificado TLS sem chave", map[string]string{"WEATHER_API_KEY": "check-key", "TLS_CERT_FILE": "cert.pem"}, nil, 1, "FAIL: TLS_CERT_FILE and TLS_KEY_FILE must be set together"},
+		{"Socket unix", map[string]string{"WEATHER_API_KEY": "check-key", "LISTEN_ADDR": "unix:///var/run/weather.sock"}, nil, 0, "unix:///var/run/weather.sock"},
+		{"Modo do socket inválido", map[string]string{"WEATHER_API_KEY": "check-key", "LISTEN_SOCKET_MODE": "rw"}, nil, 1, `FAIL: invalid LISTEN_SOCKET_MODE "rw"`},
+		{"Proxies confiáveis", map[string]string{"WEATHER_API_KEY": "check-key", "TRUSTED_PROXIES": "10.0.0.0/8,192.0.2.1"}, nil, 0, "TRUSTED_PROXIES"},
+		{"Proxy confiável inválido", map[string]string{"WEATHER_API_KEY": "check-key", "TRUSTED_PROXIES": "proxy.local"}, nil, 1, `FAIL: invalid TRUSTED_PROXIES entry "proxy.local"`},
+		{"Objetivo de SLO igual a 1", map[string]string{"WEATHER_API_KEY": "check-key", "SLO_LATENCY_TARGET": "800ms", "SLO_OBJECTIVE": "1"}, nil, 1, "FAIL: SLO_OBJECTIVE must be between 0 and 1, got 1"},
+		{"Janela de SLO menor que os buckets", map[string]string{"WEATHER_API_KEY": "check-key", "SLO_LATENCY_TARGET": "800ms", "SLO_WINDOW": "30ns"}, nil, 1, "FAIL: SLO_WINDOW must be at least 60ns, got 30ns"},
+		{"SLO desligado ignora o objetivo", map[string]string{"WEATHER_API_KEY": "check-key", "SLO_OBJECTIVE": "1"}, nil, 0, "check passed"},
+		{"TTL inválido", map[string]string{"WEATHER_API_KEY": "check-key", "WEATHER_CACHE_TTL": "-1s"}, nil, 1, "FAIL: must be positive"},
+		{"Probes bem-sucedidos", map[string]string{
+			"WEATHER_API_KEY":      "check-key",
+			"VIACEP_BASE_URLS":     viaCEP.URL,
+			"WEATHERAPI_BASE_URLS": weatherAPI.URL,
+		}, []string{"-probe"}, 0, "probe"},
+		{"Probe com chave inválida", map[string]string{
+			"WEATHER_API_KEY":      "wrong-key",
+			"VIACEP_BASE_URLS":     viaCEP.URL,
+			"WEATHERAPI_BASE_URLS": weatherAPI.URL,
+		}, []string{"-probe"}, 1, "FAIL: weather API error: 401"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			var out bytes.Buffer
+			if code := runCheck(tt.args, &out); code != tt.expectedCode {
+				t.Errorf("Expected exit code %d, got %d:\n%s", tt.expectedCode, code, out.String())
+			}
+			if !strings.Contains(out.String(), tt.expectedRow) {
+				t.Errorf("Expected output to contain %q, got:\n%s", tt.expectedRow, out.String())
+			}
+		})
+	}
+}
+
+func TestRunCheck_ProbeRedactsWeatherAPIKey(t *testing.T) {
+	viaCEP := newFakeViaCEP(t)
+	viaCEP.set(func(f *fakeViaCEP) {
+		f.addresses["01001000"] = ViaCEPResponse{CEP: "01001-000", Localidade: "São Paulo", UF: "SP", IBGE: "3550308"}
+	})
+	t.Setenv("WEATHER_API_KEY", "probe-secret-key")
+	t.Setenv("VIACEP_BASE_URLS", viaCEP.URL)
+	t.Setenv("WEATHERAPI_BASE_URLS", "http://127.0.0.1:1")
+
+	var out bytes.Buffer
+	if code := runCheck([]string{"-probe"}, &out); code != 1 {
+		t.Errorf("Expected exit code 1, got %d:\n%s", code, out.String())
+	}
+	if !strings.Contains(out.String(), "FAIL: Get \"http://127.0.0.1:1/forecast.json\"") {
+		t.Errorf("Expected the unreachable WeatherAPI probe to fail, got:\n%s", out.String())
+	}
+	if strings.Contains(out.String(), "probe-secret-key") {
+		t.Errorf("Expected the WeatherAPI key to be redacted, got:\n%s", out.String())
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+const (
+	clientTagHeader  = "X-Client-Tag"
+	defaultClientTag = "unknown"
+	otherClientTag   = "other"
+)
+
+type contextKey string
+
+const clientTagContextKey contextKey = "client_tag"
+
+var clientTagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,63}$`)
+
+func parseClientTagAllowlist(raw string) map[string]bool {
+	allowlist := make(map[string]bool)
+	for _, tag := range strings.Split(raw, ",") {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" {
+			allowlist[tag] = true
+		}
+	}
+	return allowlist
+}
+
+// resolveClientTag validates the raw header value and maps tags outside the
+// configured allowlist to "other", keeping the number of distinct tags bounded.
+func (app *App) resolveClientTag(raw string) (string, bool) {
+	tag := strings.ToLower(strings.TrimSpace(raw))
+	if tag == "" {
+		return defaultClientTag, true
+	}
+	if !clientTagPattern.MatchString(tag) {
+		return "", false
+	}
+	if len(app.clientTags) > 0 && !app.clientTags[tag] {
+		return otherClientTag, true
+	}
+	return tag, true
+}
+
+func (app *App) clientTagMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tag, ok := app.resolveClientTag(r.Header.Get(clientTagHeader))
+		if !ok {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{Message: "invalid client tag"})
+			return
+		}
+		ctx := context.WithValue(r.Context(), clientTagContextKey, tag)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func clientTagFromContext(ctx context.Context) string {
+	if tag, ok := ctx.Value(clientTagContextKey).(string); ok {
+		return tag
+	}
+	return defaultClientTag
+}
+
+// requestClientTag resolves the tag for middleware that runs outside
+// clientTagMiddleware. Invalid tags, rejected there with 400, count as
+// "other".
+func (app *App) requestClientTag(r *http.Request) string {
+	tag, ok := app.resolveClientTag(r.Header.Get(clientTagHeader))
+	if !ok {
+		return otherClientTag
+	}
+	return tag
+}
+
+// metricsClientTag keeps the metrics label bounded: without an allowlist
+// any well-formed tag is accepted, so only "unknown" and "other" are used.
+func (app *App) metricsClientTag(r *http.Request) string {
+	tag := app.requestClientTag(r)
+	if len(app.clientTags) == 0 && tag != defaultClientTag {
+		return otherClientTag
+	}
+	return tag
+}
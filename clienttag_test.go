@@ -0,0 +1,74 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResolveClientTag(t *testing.T) {
+	app := &App{}
+	restricted := &App{clientTags: parseClientTagAllowlist("mobile-app, dashboard")}
+
+	tests := []struct {
+		name     string
+		app      *App
+		raw      string
+		expected string
+		valid    bool
+	}{
+		{"Sem tag", app, "", defaultClientTag, true},
+		{"Tag simples", app, "mobile-app", "mobile-app", true},
+		{"Tag normalizada", app, "  Mobile-App ", "mobile-app", true},
+		{"Tag com caracteres inválidos", app, "mobile app", "", false},
+		{"Tag muito longa", app, strings.Repeat("a", 65), "", false},
+		{"Tag na allowlist", restricted, "dashboard", "dashboard", true},
+		{"Tag fora da allowlist", restricted, "scraper", otherClientTag, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tag, ok := tt.app.resolveClientTag(tt.raw)
+			if ok != tt.valid {
+				t.Fatalf("resolveClientTag(%q) valid = %v, expected %v", tt.raw, ok, tt.valid)
+			}
+			if tag != tt.expected {
+				t.Errorf("resolveClientTag(%q) = %q, expected %q", tt.raw, tag, tt.expected)
+			}
+		})
+	}
+}
+
+func TestClientTagMiddleware(t *testing.T) {
+	app := &App{}
+	var seen string
+	handler := app.clientTagMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = clientTagFromContext(r.Context())
+	}))
+
+	t.Run("Tag válida propagada no contexto", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/weather/01310100", nil)
+		req.Header.Set(clientTagHeader, "ETL")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", rr.Code)
+		}
+		if seen != "etl" {
+			t.Errorf("Expected client tag 'etl', got '%s'", seen)
+		}
+	})
+
+	t.Run("Tag inválida rejeitada", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/weather/01310100", nil)
+		req.Header.Set(clientTagHeader, "<script>")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", rr.Code)
+		}
+	})
+}
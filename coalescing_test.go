@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type blockingHTTPClient struct {
+	body     string
+	calls    int32
+	canceled int32
+	release  chan struct{}
+}
+
+func (c *blockingHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&c.calls, 1)
+	select {
+	case <-c.release:
+	case <-req.Context().Done():
+		atomic.AddInt32(&c.canceled, 1)
+		return nil, req.Context().Err()
+	}
+	return &http.Response{
+		StatusCode: 200,
+		Body:       io.NopCloser(strings.NewReader(c.body)),
+		Header:     make(http.Header),
+	}, nil
+}
+
+func runConcurrently(n int, release chan struct{}, fn func()) {
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fn()
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+}
+
+func TestCEPService_CoalescesConcurrentLookups(t *testing.T) {
+	client := &blockingHTTPClient{body: `{"localidade": "São Paulo", "uf": "SP"}`, release: make(chan struct{})}
+	service := NewCEPService(client)
+
+	var failures int32
+	runConcurrently(10, client.release, func() {
+		if result, err := service.GetCEPInfo(context.Background(), "01310100"); err != nil || result.State != "SP" {
+			atomic.AddInt32(&failures, 1)
+		}
+	})
+
+	if failures != 0 {
+		t.Errorf("Expected every caller to get the shared result, %d failed", failures)
+	}
+	if calls := atomic.LoadInt32(&client.calls); calls != 1 {
+		t.Errorf("Expected 1 upstream call, got %d", calls)
+	}
+}
+
+func TestWeatherService_CoalescesConcurrentLookups(t *testing.T) {
+	client := &blockingHTTPClient{body: `{"current": {"temp_c": 25.0}}`, release: make(chan struct{})}
+	service := NewWeatherService(client, "test-api-key")
+
+	var failures int32
+	runConcurrently(10, client.release, func() {
+		if result, err := service.GetTemperature(context.Background(), "São Paulo", "SP"); err != nil || result.TempC != 25.0 {
+			atomic.AddInt32(&failures, 1)
+		}
+	})
+
+	if failures != 0 {
+		t.Errorf("Expected every caller to get the shared result, %d failed", failures)
+	}
+	if calls := atomic.LoadInt32(&client.calls); calls != 1 {
+		t.Errorf("Expected 1 upstream call, got %d", calls)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+type ConditionInfo struct {
+	Code string `json:"code"`
+	Text string `json:"text"`
+}
+
+// weatherAPIConditions maps WeatherAPI condition codes onto our condition
+// enum. Codes not listed here map to "unknown".
+var weatherAPIConditions = map[int]string{
+	1000: "clear",
+	1003: "partly_cloudy",
+	1006: "cloudy",
+	1009: "overcast",
+	1030: "mist",
+	1135: "fog", 1147: "fog",
+	1072: "drizzle", 1150: "drizzle", 1153: "drizzle", 1168: "drizzle", 1171: "drizzle",
+	1063: "light_rain", 1180: "light_rain", 1183: "light_rain", 1240: "light_rain",
+	1186: "rain", 1189: "rain", 1243: "rain",
+	1192: "heavy_rain", 1195: "heavy_rain", 1246: "heavy_rain",
+	1198: "freezing_rain", 1201: "freezing_rain",
+	1069: "sleet", 1204: "sleet", 1207: "sleet", 1237: "sleet", 1249: "sleet", 1252: "sleet", 1261: "sleet", 1264: "sleet",
+	1066: "snow", 1114: "snow", 1117: "snow", 1210: "snow", 1213: "snow", 1216: "snow", 1219: "snow", 1222: "snow", 1225: "snow", 1255: "snow", 1258: "snow",
+	1087: "thunderstorm", 1273: "thunderstorm", 1276: "thunderstorm", 1279: "thunderstorm", 1282: "thunderstorm",
+}
+
+func conditionFromWeatherAPICode(code int) string {
+	if condition, ok := weatherAPIConditions[code]; ok {
+		return condition
+	}
+	return "unknown"
+}
+
+// wmoConditions maps the WMO weather interpretation codes used by Open-Meteo
+// onto our condition enum.
+var wmoConditions = map[int]string{
+	0: "clear",
+	1: "partly_cloudy", 2: "partly_cloudy",
+	3:  "overcast",
+	45: "fog", 48: "fog",
+	51: "drizzle", 53: "drizzle", 55: "drizzle",
+	56: "freezing_rain", 57: "freezing_rain", 66: "freezing_rain", 67: "freezing_rain",
+	61: "light_rain", 80: "light_rain",
+	63: "rain", 81: "rain",
+	65: "heavy_rain", 82: "heavy_rain",
+	71: "snow", 73: "snow", 75: "snow", 77: "snow", 85: "snow", 86: "snow",
+	95: "thunderstorm", 96: "thunderstorm", 99: "thunderstorm",
+}
+
+func conditionFromWMOCode(code int) string {
+	if condition, ok := wmoConditions[code]; ok {
+		return condition
+	}
+	return "unknown"
+}
+
+func newConditionInfo(observation *Observation, lang string) *ConditionInfo {
+	return &ConditionInfo{
+		Code: observation.Condition,
+		Text: phrase(lang, "condition."+observation.Condition),
+	}
+}
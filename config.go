@@ -0,0 +1,308 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+type Config struct {
+	Port                   string
+	WeatherProvider        string
+	WeatherAPIKey          string
+	OpenMeteoGeocodingURL  string
+	OpenMeteoForecastURL   string
+	ViaCEPBaseURLs         []string
+	WeatherAPIBaseURLs     []string
+	EndpointHealthInterval time.Duration
+	RedisAddr              string
+	RedisPassword          string
+	RedisDB                int
+	CEPCacheTTL            time.Duration
+	CEPCacheL1Size         int
+	CEPCacheL1TTL          time.Duration
+	WeatherCacheSize       int
+	WeatherCacheTTL        time.Duration
+	WeatherCacheStaleTTL   time.Duration
+	ClientTagAllowlist     string
+	ViaCEPProxyEnabled     bool
+	CDNPolicy              CDNCachePolicy
+	AdminToken             string
+	WarmupCEPs             []string
+	RequestBudget          time.Duration
+	CEPBudgetShare         float64
+	CacheTTLJitter         float64
+	HTTPClientTimeout      time.Duration
+	DialTimeout            time.Duration
+	ViaCEPRetry            RetryPolicy
+	WeatherAPIRetry        RetryPolicy
+	BreakerThreshold       int
+	BreakerCooldown        time.Duration
+	CEPFallbackProviders   []string
+	BrasilAPIBaseURL       string
+	AwesomeAPIBaseURL      string
+	CoordinateRadiusKm     float64
+	HedgeAfter             time.Duration
+	OTLPEndpoint           string
+	TrustedProxies         trustedProxies
+	Abuse                  AbusePolicy
+	SLO                    SLOPolicy
+	LogLevel               string
+	LogFormat              string
+	AccessLog              string
+	SentryDSN              string
+	PprofAddr              string
+	PprofAdmin             bool
+	Watchdog               WatchdogPolicy
+	SuggestionRules        []SuggestionRule
+	Energy                 EnergyPolicy
+	ShutdownTimeout        time.Duration
+	Server                 ServerPolicy
+	RiskProfiles           map[string]RiskWeights
+	TLS                    TLSPolicy
+	ListenAddr             string
+	ListenSocketMode       fs.FileMode
+	InternalAddr           string
+	WeatherQuotaFallback   string
+}
+
+func loadConfig() (Config, error) {
+	viper.AutomaticEnv()
+	viper.SetDefault("PORT", "8080")
+	viper.SetDefault("ENDPOINT_HEALTH_INTERVAL", "30s")
+	viper.SetDefault("CEP_CACHE_TTL", "24h")
+	viper.SetDefault("CEP_CACHE_L1_TTL", "1m")
+	viper.SetDefault("WEATHER_CACHE_SIZE", 1000)
+	viper.SetDefault("WEATHER_CACHE_TTL", "5m")
+	viper.SetDefault("REQUEST_BUDGET", "10s")
+	viper.SetDefault("CEP_BUDGET_SHARE", 0.4)
+	viper.SetDefault("HTTP_CLIENT_TIMEOUT", "10s")
+	viper.SetDefault("DIAL_TIMEOUT", "3s")
+	viper.SetDefault("CIRCUIT_BREAKER_THRESHOLD", 5)
+	viper.SetDefault("COORDINATE_OVERRIDE_RADIUS_KM", 30)
+	viper.SetDefault("WEATHER_PROVIDER", weatherProviderWeatherAPI)
+	viper.SetDefault("OPEN_METEO_GEOCODING_URL", defaultOpenMeteoGeocodingURL)
+	viper.SetDefault("OPEN_METEO_FORECAST_URL", defaultOpenMeteoForecastURL)
+	viper.SetDefault("ABUSE_MIN_REQUESTS", 30)
+	viper.SetDefault("ABUSE_MAX_ERROR_RATIO", 0.8)
+	viper.SetDefault("ABUSE_SEQUENTIAL_RUN", 20)
+	viper.SetDefault("SLO_OBJECTIVE", 0.99)
+	viper.SetDefault("SLO_WINDOW", "1h")
+	viper.SetDefault("SLO_BURN_RATE_ALERT", 14.4)
+	viper.SetDefault("WATCHDOG_INTERVAL", "30s")
+	viper.SetDefault("WATCHDOG_MAX_GOROUTINES", 10000)
+	viper.SetDefault("WATCHDOG_MAX_QUEUE_DEPTH", 1000)
+	viper.SetDefault("WATCHDOG_MAX_LAG", "100ms")
+	viper.SetDefault("ENERGY_BASE_TEMP", defaultEnergyBaseTempC)
+	viper.SetDefault("ENERGY_LOAD_PER_DEGREE_DAY", defaultEnergyLoadPerDegreeDay)
+	viper.SetDefault("LISTEN_SOCKET_MODE", "0660")
+	viper.SetDefault("SHUTDOWN_TIMEOUT", "10s")
+	viper.SetDefault("TLS_AUTOCERT_CACHE_DIR", "autocert-cache")
+	viper.SetDefault("SERVER_READ_HEADER_TIMEOUT", "5s")
+	viper.SetDefault("SERVER_READ_TIMEOUT", "30s")
+	viper.SetDefault("SERVER_WRITE_TIMEOUT", "75s")
+	viper.SetDefault("SERVER_IDLE_TIMEOUT", "120s")
+	viper.SetDefault("SERVER_MAX_HEADER_BYTES", 1<<16)
+	viper.SetDefault("LOG_LEVEL", "info")
+	viper.SetDefault("LOG_FORMAT", "json")
+	viper.SetDefault("ACCESS_LOG", accessLogOff)
+	// Fallbacks send users' CEPs to more third parties, so they are opt-in.
+	viper.SetDefault("CEP_FALLBACK_PROVIDERS", "")
+	viper.SetDefault("BRASILAPI_BASE_URL", defaultBrasilAPIBaseURL)
+	viper.SetDefault("AWESOMEAPI_BASE_URL", defaultAwesomeAPIBaseURL)
+	viper.SetDefault("CIRCUIT_BREAKER_COOLDOWN", "30s")
+	for _, prefix := range []string{"VIACEP", "WEATHERAPI"} {
+		viper.SetDefault(prefix+"_RETRY_MAX_ATTEMPTS", 3)
+		viper.SetDefault(prefix+"_RETRY_BACKOFF", "100ms")
+		viper.SetDefault(prefix+"_RETRY_MAX_BACKOFF", "1s")
+	}
+
+	cfg := Config{
+		Port:                   viper.GetString("PORT"),
+		WeatherProvider:        strings.ToLower(viper.GetString("WEATHER_PROVIDER")),
+		WeatherAPIKey:          viper.GetString("WEATHER_API_KEY"),
+		OpenMeteoGeocodingURL:  viper.GetString("OPEN_METEO_GEOCODING_URL"),
+		OpenMeteoForecastURL:   viper.GetString("OPEN_METEO_FORECAST_URL"),
+		ViaCEPBaseURLs:         parseBaseURLs(viper.GetString("VIACEP_BASE_URLS"), defaultViaCEPBaseURL),
+		WeatherAPIBaseURLs:     parseBaseURLs(viper.GetString("WEATHERAPI_BASE_URLS"), defaultWeatherAPIBaseURL),
+		EndpointHealthInterval: viper.GetDuration("ENDPOINT_HEALTH_INTERVAL"),
+		RedisAddr:              viper.GetString("REDIS_ADDR"),
+		RedisPassword:          viper.GetString("REDIS_PASSWORD"),
+		RedisDB:                viper.GetInt("REDIS_DB"),
+		CEPCacheTTL:            viper.GetDuration("CEP_CACHE_TTL"),
+		CEPCacheL1Size:         viper.GetInt("CEP_CACHE_L1_SIZE"),
+		CEPCacheL1TTL:          viper.GetDuration("CEP_CACHE_L1_TTL"),
+		WeatherCacheSize:       viper.GetInt("WEATHER_CACHE_SIZE"),
+		WeatherCacheTTL:        viper.GetDuration("WEATHER_CACHE_TTL"),
+		WeatherCacheStaleTTL:   viper.GetDuration("WEATHER_CACHE_STALE_TTL"),
+		ClientTagAllowlist:     viper.GetString("CLIENT_TAG_ALLOWLIST"),
+		ViaCEPProxyEnabled:     viper.GetBool("VIACEP_PROXY_ENABLED"),
+		CDNPolicy: CDNCachePolicy{
+			MaxAge:  viper.GetDuration("CACHE_MAX_AGE"),
+			SMaxAge: viper.GetDuration("CACHE_S_MAXAGE"),
+		},
+		AdminToken:           viper.GetString("ADMIN_TOKEN"),
+		WarmupCEPs:           parseList(viper.GetString("WARMUP_CEPS")),
+		RequestBudget:        viper.GetDuration("REQUEST_BUDGET"),
+		CEPBudgetShare:       viper.GetFloat64("CEP_BUDGET_SHARE"),
+		CacheTTLJitter:       viper.GetFloat64("CACHE_TTL_JITTER"),
+		HTTPClientTimeout:    viper.GetDuration("HTTP_CLIENT_TIMEOUT"),
+		DialTimeout:          viper.GetDuration("DIAL_TIMEOUT"),
+		ViaCEPRetry:          loadRetryPolicy("VIACEP"),
+		WeatherAPIRetry:      loadRetryPolicy("WEATHERAPI"),
+		BreakerThreshold:     viper.GetInt("CIRCUIT_BREAKER_THRESHOLD"),
+		BreakerCooldown:      viper.GetDuration("CIRCUIT_BREAKER_COOLDOWN"),
+		CEPFallbackProviders: parseProviderList(viper.GetString("CEP_FALLBACK_PROVIDERS")),
+		BrasilAPIBaseURL:     viper.GetString("BRASILAPI_BASE_URL"),
+		AwesomeAPIBaseURL:    viper.GetString("AWESOMEAPI_BASE_URL"),
+		CoordinateRadiusKm:   viper.GetFloat64("COORDINATE_OVERRIDE_RADIUS_KM"),
+		HedgeAfter:           viper.GetDuration("HEDGE_AFTER"),
+		LogLevel:             viper.GetString("LOG_LEVEL"),
+		LogFormat:            viper.GetString("LOG_FORMAT"),
+		OTLPEndpoint:         firstNonEmpty(viper.GetString("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"), viper.GetString("OTEL_EXPORTER_OTLP_ENDPOINT")),
+		AccessLog:            strings.ToLower(viper.GetString("ACCESS_LOG")),
+		SentryDSN:            viper.GetString("SENTRY_DSN"),
+		PprofAddr:            viper.GetString("PPROF_ADDR"),
+		PprofAdmin:           viper.GetBool("PPROF_ADMIN"),
+		ListenAddr:           viper.GetString("LISTEN_ADDR"),
+		InternalAddr:         viper.GetString("INTERNAL_ADDR"),
+		WeatherQuotaFallback: strings.ToLower(viper.GetString("WEATHER_QUOTA_FALLBACK")),
+		ShutdownTimeout:      viper.GetDuration("SHUTDOWN_TIMEOUT"),
+		Abuse: AbusePolicy{
+			Window:        viper.GetDuration("ABUSE_WINDOW"),
+			MinRequests:   viper.GetInt("ABUSE_MIN_REQUESTS"),
+			MaxErrorRatio: viper.GetFloat64("ABUSE_MAX_ERROR_RATIO"),
+			SequentialRun: viper.GetInt("ABUSE_SEQUENTIAL_RUN"),
+			BlockFor:      viper.GetDuration("ABUSE_BLOCK_DURATION"),
+		},
+		Watchdog: WatchdogPolicy{
+			Interval:      viper.GetDuration("WATCHDOG_INTERVAL"),
+			MaxGoroutines: viper.GetInt("WATCHDOG_MAX_GOROUTINES"),
+			MaxQueueDepth: viper.GetInt("WATCHDOG_MAX_QUEUE_DEPTH"),
+			MaxLag:        viper.GetDuration("WATCHDOG_MAX_LAG"),
+		},
+		TLS: TLSPolicy{
+			CertFile:         viper.GetString("TLS_CERT_FILE"),
+			KeyFile:          viper.GetString("TLS_KEY_FILE"),
+			AutocertDomains:  parseList(viper.GetString("TLS_AUTOCERT_DOMAINS")),
+			AutocertCacheDir: viper.GetString("TLS_AUTOCERT_CACHE_DIR"),
+			AutocertEmail:    viper.GetString("TLS_AUTOCERT_EMAIL"),
+			RedirectAddr:     viper.GetString("TLS_REDIRECT_ADDR"),
+		},
+		Server: ServerPolicy{
+			ReadHeaderTimeout: viper.GetDuration("SERVER_READ_HEADER_TIMEOUT"),
+			ReadTimeout:       viper.GetDuration("SERVER_READ_TIMEOUT"),
+			WriteTimeout:      viper.GetDuration("SERVER_WRITE_TIMEOUT"),
+			IdleTimeout:       viper.GetDuration("SERVER_IDLE_TIMEOUT"),
+			MaxHeaderBytes:    viper.GetInt("SERVER_MAX_HEADER_BYTES"),
+		},
+		Energy: EnergyPolicy{
+			BaseTempC:        viper.GetFloat64("ENERGY_BASE_TEMP"),
+			LoadPerDegreeDay: viper.GetFloat64("ENERGY_LOAD_PER_DEGREE_DAY"),
+		},
+		SLO: SLOPolicy{
+			LatencyTarget: viper.GetDuration("SLO_LATENCY_TARGET"),
+			Objective:     viper.GetFloat64("SLO_OBJECTIVE"),
+			Window:        viper.GetDuration("SLO_WINDOW"),
+			BurnRateAlert: viper.GetFloat64("SLO_BURN_RATE_ALERT"),
+		},
+	}
+	switch cfg.AccessLog {
+	case accessLogOff, accessLogJSON, accessLogCombined:
+	default:
+		return cfg, fmt.Errorf("unknown ACCESS_LOG %q", cfg.AccessLog)
+	}
+	if cfg.ListenAddr == "" {
+		cfg.ListenAddr = ":" + cfg.Port
+	}
+	mode, err := strconv.ParseUint(viper.GetString("LISTEN_SOCKET_MODE"), 8, 32)
+	if err != nil || mode > 0o777 {
+		return cfg, fmt.Errorf("invalid LISTEN_SOCKET_MODE %q", viper.GetString("LISTEN_SOCKET_MODE"))
+	}
+	cfg.ListenSocketMode = fs.FileMode(mode)
+	if err := cfg.TLS.validate(); err != nil {
+		return cfg, err
+	}
+	if err := cfg.SLO.validate(); err != nil {
+		return cfg, err
+	}
+	if cfg.TrustedProxies, err = parseTrustedProxies(viper.GetString("TRUSTED_PROXIES")); err != nil {
+		return cfg, err
+	}
+	if path := viper.GetString("SUGGESTION_RULES_FILE"); path != "" {
+		rules, err := loadSuggestionRules(path)
+		if err != nil {
+			return cfg, fmt.Errorf("SUGGESTION_RULES_FILE: %w", err)
+		}
+		cfg.SuggestionRules = rules
+	}
+	if path := viper.GetString("RISK_PROFILES_FILE"); path != "" {
+		profiles, err := loadRiskProfiles(path)
+		if err != nil {
+			return cfg, fmt.Errorf("RISK_PROFILES_FILE: %w", err)
+		}
+		cfg.RiskProfiles = profiles
+	}
+	switch cfg.WeatherProvider {
+	case weatherProviderWeatherAPI:
+		if cfg.WeatherAPIKey == "" {
+			return cfg, errors.New("WEATHER_API_KEY environment variable is required")
+		}
+	case weatherProviderOpenMeteo:
+	default:
+		return cfg, fmt.Errorf("unknown WEATHER_PROVIDER %q", cfg.WeatherProvider)
+	}
+	if cfg.WeatherQuotaFallback != "" && cfg.WeatherQuotaFallback != weatherProviderOpenMeteo {
+		return cfg, fmt.Errorf("unknown WEATHER_QUOTA_FALLBACK %q", cfg.WeatherQuotaFallback)
+	}
+	return cfg, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// parseList splits a comma-separated setting, dropping blank items.
+func parseList(raw string) []string {
+	var items []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseProviderList lowercases the provider names so they match the registry.
+func parseProviderList(raw string) []string {
+	var names []string
+	for _, name := range parseList(raw) {
+		names = append(names, strings.ToLower(name))
+	}
+	return names
+}
+
+func loadRetryPolicy(prefix string) RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: viper.GetInt(prefix + "_RETRY_MAX_ATTEMPTS"),
+		Backoff:     viper.GetDuration(prefix + "_RETRY_BACKOFF"),
+		MaxBackoff:  viper.GetDuration(prefix + "_RETRY_MAX_BACKOFF"),
+	}
+}
+
+func maskSecret(secret string) string {
+	if len(secret) <= 8 {
+		return "****"
+	}
+	return secret[:4] + "..." + secret[len(secret)-4:]
+}
@@ -0,0 +1,22 @@
+package main
+
+import "testing"
+
+func TestParseList(t *testing.T) {
+	items := parseList(" 01310-100, 20040002 ,,")
+	if len(items) != 2 || items[0] != "01310-100" || items[1] != "20040002" {
+		t.Errorf("Unexpected items: %v", items)
+	}
+	if items := parseList(""); len(items) != 0 {
+		t.Errorf("Expected no items, got %v", items)
+	}
+}
+
+func TestParseProviderList(t *testing.T) {
+	if names := parseProviderList(" BrasilAPI ,awesomeapi"); len(names) != 2 || names[0] != "brasilapi" {
+		t.Errorf("Unexpected providers: %v", names)
+	}
+	if names := parseProviderList(""); names != nil {
+		t.Errorf("Expected no providers for an empty list, got %v", names)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCEPService_CancelsUpstreamWithCaller(t *testing.T) {
+	client := &blockingHTTPClient{release: make(chan struct{})}
+	service := NewCEPService(client)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	if _, err := service.GetCEPInfo(ctx, "01310100"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	if !waitFor(t, func() bool { return atomic.LoadInt32(&client.canceled) == 1 }) {
+		t.Error("Expected the upstream request to be canceled")
+	}
+}
+
+func TestWeatherService_SharedLookupSurvivesLeaderCancellation(t *testing.T) {
+	client := &blockingHTTPClient{body: `{"current": {"temp_c": 25.0}}`, release: make(chan struct{})}
+	service := NewWeatherService(client, "test-api-key")
+	leaderCtx, cancelLeader := context.WithCancel(context.Background())
+
+	leaderDone := make(chan error)
+	go func() {
+		_, err := service.GetTemperature(leaderCtx, "São Paulo", "SP")
+		leaderDone <- err
+	}()
+	waitFor(t, func() bool { return atomic.LoadInt32(&client.calls) == 1 })
+
+	followerDone := make(chan error)
+	go func() {
+		_, err := service.GetTemperature(context.Background(), "São Paulo", "SP")
+		followerDone <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancelLeader()
+	if err := <-leaderDone; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected leader to see context.Canceled, got %v", err)
+	}
+
+	close(client.release)
+	if err := <-followerDone; err != nil {
+		t.Errorf("Expected follower to retry with its own context, got %v", err)
+	}
+}
+
+func TestWeatherService_FollowersShareRetryAfterLeaderCancellation(t *testing.T) {
+	client := &blockingHTTPClient{body: `{"current": {"temp_c": 25.0}}`, release: make(chan struct{})}
+	service := NewWeatherService(client, "test-api-key")
+	leaderCtx, cancelLeader := context.WithCancel(context.Background())
+
+	go service.GetTemperature(leaderCtx, "São Paulo", "SP")
+	waitFor(t, func() bool { return atomic.LoadInt32(&client.calls) == 1 })
+
+	const followers = 10
+	followersDone := make(chan error, followers)
+	for i := 0; i < followers; i++ {
+		go func() {
+			_, err := service.GetTemperature(context.Background(), "São Paulo", "SP")
+			followersDone <- err
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	cancelLeader()
+	waitFor(t, func() bool { return atomic.LoadInt32(&client.calls) >= 2 })
+	time.Sleep(20 * time.Millisecond)
+	close(client.release)
+
+	for i := 0; i < followers; i++ {
+		if err := <-followersDone; err != nil {
+			t.Errorf("Expected followers to get the retried result, got %v", err)
+		}
+	}
+	if calls := atomic.LoadInt32(&client.calls); calls != 2 {
+		t.Errorf("Expected a single retry shared by the followers, got %d upstream calls", calls)
+	}
+}
+
+func TestHandleWeatherByCEP_ClientDisconnect(t *testing.T) {
+	client := &blockingHTTPClient{release: make(chan struct{})}
+	app := NewApp(NewCEPService(client), NewWeatherService(client, "test-api-key"))
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest("GET", "/weather/01310100", nil).WithContext(ctx)
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	app.setupRoutes().ServeHTTP(httptest.NewRecorder(), req)
+
+	if calls := atomic.LoadInt32(&client.calls); calls != 1 {
+		t.Errorf("Expected only the CEP lookup to start, got %d upstream calls", calls)
+	}
+	if !waitFor(t, func() bool { return atomic.LoadInt32(&client.canceled) == 1 }) {
+		t.Error("Expected the upstream request to be canceled")
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+)
+
+var errInvalidCoordinates = errors.New("invalid coordinates")
+
+type coordinates struct {
+	Lat float64
+	Lon float64
+}
+
+// parseCoordinateOverride reads ?lat=&lon=. Both must be given together;
+// neither means no override.
+func parseCoordinateOverride(r *http.Request) (*coordinates, error) {
+	rawLat, rawLon := r.URL.Query().Get("lat"), r.URL.Query().Get("lon")
+	if rawLat == "" && rawLon == "" {
+		return nil, nil
+	}
+	lat, latErr := strconv.ParseFloat(rawLat, 64)
+	lon, lonErr := strconv.ParseFloat(rawLon, 64)
+	if latErr != nil || lonErr != nil || math.Abs(lat) > 90 || math.Abs(lon) > 180 {
+		return nil, errInvalidCoordinates
+	}
+	return &coordinates{Lat: lat, Lon: lon}, nil
+}
+
+// distanceKm is the great-circle distance between two points.
+func distanceKm(a, b coordinates) float64 {
+	const earthRadiusKm = 6371
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := toRad(b.Lat - a.Lat)
+	dLon := toRad(b.Lon - a.Lon)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(toRad(a.Lat))*math.Cos(toRad(b.Lat))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
+
+// withinMunicipality approximates the municipality bounds by a radius around
+// the point the weather provider resolves the city to; we have no boundary
+// polygons.
+func withinMunicipality(point coordinates, city *Observation, radiusKm float64) bool {
+	return distanceKm(point, coordinates{Lat: city.Lat, Lon: city.Lon}) <= radiusKm
+}
+
+// LookupTemperatureAt queries weather at a point. Coordinates are rounded to
+// ~100m for the cache key so nearby requests share an entry.
+func (s *WeatherService) LookupTemperatureAt(ctx context.Context, point coordinates) (*Observation, string, error) {
+	key := fmt.Sprintf("coord:%.3f,%.3f", point.Lat, point.Lon)
+	return s.lookup(ctx, key, weatherLocation{Point: &point})
+}
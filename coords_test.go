@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseCoordinateOverride(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    *coordinates
+		wantErr bool
+	}{
+		{"Sem coordenadas", "", nil, false},
+		{"Coordenadas válidas", "?lat=-23.56&lon=-46.65", &coordinates{Lat: -23.56, Lon: -46.65}, false},
+		{"Só latitude", "?lat=-23.56", nil, true},
+		{"Valor não numérico", "?lat=abc&lon=-46.65", nil, true},
+		{"Latitude fora do intervalo", "?lat=-91&lon=-46.65", nil, true},
+		{"Longitude fora do intervalo", "?lat=-23.56&lon=181", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCoordinateOverride(httptest.NewRequest("GET", "/weather/01310100"+tt.query, nil))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error=%v, got %v", tt.wantErr, err)
+			}
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("Expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDistanceKm(t *testing.T) {
+	saoPaulo := coordinates{Lat: -23.55, Lon: -46.64}
+	rio := coordinates{Lat: -22.91, Lon: -43.17}
+	if d := distanceKm(saoPaulo, rio); math.Abs(d-361) > 5 {
+		t.Errorf("Expected about 361km between São Paulo and Rio, got %.1f", d)
+	}
+	if d := distanceKm(saoPaulo, saoPaulo); d != 0 {
+		t.Errorf("Expected 0km for the same point, got %.1f", d)
+	}
+}
+
+func TestE2E_CoordinateOverride(t *testing.T) {
+	env := newE2EEnv(t, func(cfg *Config) { cfg.CoordinateRadiusKm = 30 })
+	env.weatherAPI.set(func(f *fakeWeatherAPI) { f.temperatures["-23.5600,-46.6500"] = 19.0 })
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantTempC  float64
+		wantIgnore bool
+	}{
+		{"Coordenadas dentro do município", "/weather/01310100?lat=-23.56&lon=-46.65", http.StatusOK, 19.0, false},
+		{"Sem coordenadas usa a cidade", "/weather/01310100", http.StatusOK, 22.5, false},
+		{"Coordenadas fora do município usam a cidade", "/weather/01310100?lat=-22.91&lon=-43.17", http.StatusOK, 22.5, true},
+		{"Coordenadas inválidas", "/weather/01310100?lat=-23.56", http.StatusBadRequest, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := env.get(t, tt.path, nil)
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, resp.StatusCode, body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var response TemperatureResponse
+			if err := json.Unmarshal(body, &response); err != nil {
+				t.Fatalf("Error parsing response: %v", err)
+			}
+			if response.TempC != tt.wantTempC {
+				t.Errorf("Expected temp_C %.1f, got %.1f", tt.wantTempC, response.TempC)
+			}
+			if response.CoordinatesIgnored != tt.wantIgnore {
+				t.Errorf("Expected coordinates_ignored %v, got %v", tt.wantIgnore, response.CoordinatesIgnored)
+			}
+		})
+	}
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+)
+
+const (
+	// forecastDays is the horizon of WeatherAPI's free plan.
+	forecastDays       = 3
+	deliveryStartHour  = 8
+	deliveryEndHour    = 20
+	deliveryMaxWindows = 3
+	// deliveryGoodScore is the lowest hourly score still suggested.
+	deliveryGoodScore = 70
+)
+
+var ErrForecastUnsupported = errors.New("hourly forecast not supported by weather source")
+
+// ForecastProvider is implemented by weather providers that can return an
+// hourly forecast, in the location's local time, and active weather alerts.
+type ForecastProvider interface {
+	LookupForecast(ctx context.Context, address *Address, days int) (*Forecast, error)
+}
+
+type Forecast struct {
+	Hours  []HourlyForecast
+	Alerts []WeatherAlert
+}
+
+type HourlyForecast struct {
+	Time         time.Time
+	TempC        float64
+	PrecipMM     float64
+	WindKph      float64
+	ChanceOfRain int
+}
+
+type WeatherAlert struct {
+	Headline string `json:"headline"`
+	Event    string `json:"event"`
+	Severity string `json:"severity"`
+}
+
+type DeliveryHour struct {
+	Time         time.Time `json:"time"`
+	TempC        float64   `json:"temp_C"`
+	PrecipMM     float64   `json:"precip_mm"`
+	ChanceOfRain int       `json:"chance_of_rain"`
+	Score        int       `json:"score"`
+	Risk         string    `json:"risk"`
+}
+
+type DeliveryWindow struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	Score int       `json:"score"`
+}
+
+type DeliveryWindowResponse struct {
+	CEP     string           `json:"cep"`
+	Date    string           `json:"date,omitempty"`
+	Windows []DeliveryWindow `json:"windows"`
+	Hours   []DeliveryHour   `json:"hours"`
+}
+
+// forecastCacheKey shares a forecast between every CEP of a municipality,
+// as weatherCacheKey does for observations.
+func forecastCacheKey(weatherKey string, days int) string {
+	return fmt.Sprintf("forecast:%s|%d", weatherKey, days)
+}
+
+// LookupForecast serves forecasts the way LookupTemperature serves
+// observations: from the cache, serving stale entries while they refresh in
+// the background, with concurrent misses sharing one upstream call.
+func (s *WeatherService) LookupForecast(ctx context.Context, address *Address, days int) (*Forecast, error) {
+	if s.source != nil {
+		return nil, ErrForecastUnsupported
+	}
+	key := forecastCacheKey(weatherCacheKey(address), days)
+	query := weatherQuery(address).weatherAPIQuery()
+	if s.forecasts != nil {
+		if forecast, fresh, ok := s.forecasts.GetStale(key); ok {
+			if !fresh {
+				s.refreshing.Add(1)
+				go func() {
+					defer s.refreshing.Add(-1)
+					if _, err := s.refreshForecast(context.Background(), key, query, days); err != nil {
+						slog.Warn("Error refreshing stale forecast", "key", key, "error", redactURLError(err))
+					}
+				}()
+			}
+			return forecast, nil
+		}
+	}
+	return s.refreshForecast(ctx, key, query, days)
+}
+
+func (s *WeatherService) refreshForecast(ctx context.Context, key, query string, days int) (*Forecast, error) {
+	return doShared(ctx, &s.flight, key, func(ctx context.Context) (*Forecast, error) {
+		forecast, err := s.fetchForecast(ctx, query, days)
+		if err != nil {
+			return nil, err
+		}
+		if s.forecasts != nil {
+			s.forecasts.Set(key, forecast)
+		}
+		return forecast, nil
+	})
+}
+
+func (s *WeatherService) fetchForecast(ctx context.Context, query string, days int) (*Forecast, error) {
+	weatherResp, err := s.fetchForecastFrom(ctx, s.endpoints.Current(), query, days, true)
+	if err != nil {
+		return nil, err
+	}
+	forecast := &Forecast{Hours: weatherResp.hourlyForecast()}
+	for _, alert := range weatherResp.Alerts.Alert {
+		forecast.Alerts = append(forecast.Alerts, WeatherAlert{Headline: alert.Headline, Event: alert.Event, Severity: alert.Severity})
+	}
+	return forecast, nil
+}
+
+// hourlyForecast converts the forecast hours to the location's time zone.
+// The UTC offset is derived from localtime and localtime_epoch, which avoids
+// depending on a tz database in the container image.
+func (r *WeatherAPIResponse) hourlyForecast() []HourlyForecast {
+	zone := time.UTC
+	if local, err := time.Parse("2006-1-2 15:04", r.Location.Localtime); err == nil && r.Location.LocaltimeEpoch != 0 {
+		offset := local.Sub(time.Unix(r.Location.LocaltimeEpoch, 0)).Round(15 * time.Minute)
+		zone = time.FixedZone(r.Location.TzID, int(offset.Seconds()))
+	}
+	var hours []HourlyForecast
+	for _, day := range r.Forecast.Forecastday {
+		for _, hour := range day.Hour {
+			hours = append(hours, HourlyForecast{
+				Time:         time.Unix(hour.TimeEpoch, 0).In(zone),
+				TempC:        hour.TempC,
+				PrecipMM:     hour.PrecipMM,
+				WindKph:      hour.WindKph,
+				ChanceOfRain: hour.ChanceOfRain,
+			})
+		}
+	}
+	return hours
+}
+
+// deliveryScore rates an hour from 0 (avoid) to 100 (ideal): rain chance and
+// volume weigh most, and heat above 35°C or cold below 5°C add a penalty.
+func deliveryScore(hour HourlyForecast) int {
+	penalty := float64(hour.ChanceOfRain)*0.6 + math.Min(hour.PrecipMM*10, 40)
+	switch {
+	case hour.TempC > 35:
+		penalty += math.Min((hour.TempC-35)*10, 40)
+	case hour.TempC < 5:
+		penalty += math.Min((5-hour.TempC)*10, 40)
+	}
+	return int(math.Max(0, math.Round(100-penalty)))
+}
+
+func deliveryRisk(score int) string {
+	switch {
+	case score >= deliveryGoodScore:
+		return "low"
+	case score >= 40:
+		return "medium"
+	default:
+		return "high"
+	}
+}
+
+// scoreDeliveryHours keeps the upcoming delivery hours, of date when it is
+// set, and scores each of them.
+func scoreDeliveryHours(forecast []HourlyForecast, now time.Time, date string) []DeliveryHour {
+	hours := []DeliveryHour{}
+	for _, f := range forecast {
+		if !f.Time.Add(time.Hour).After(now) || f.Time.Hour() < deliveryStartHour || f.Time.Hour() >= deliveryEndHour {
+			continue
+		}
+		if date != "" && f.Time.Format(time.DateOnly) != date {
+			continue
+		}
+		score := deliveryScore(f)
+		hours = append(hours, DeliveryHour{
+			Time:         f.Time,
+			TempC:        f.TempC,
+			PrecipMM:     f.PrecipMM,
+			ChanceOfRain: f.ChanceOfRain,
+			Score:        score,
+			Risk:         deliveryRisk(score),
+		})
+	}
+	return hours
+}
+
+// bestDeliveryWindows groups consecutive low-risk hours into windows and
+// returns the best ones, highest average score first.
+func bestDeliveryWindows(hours []DeliveryHour) []DeliveryWindow {
+	windows := []DeliveryWindow{}
+	for i := 0; i < len(hours); {
+		if hours[i].Score < deliveryGoodScore {
+			i++
+			continue
+		}
+		j, total := i, 0
+		for j < len(hours) && hours[j].Score >= deliveryGoodScore && (j == i || hours[j].Time.Sub(hours[j-1].Time) == time.Hour) {
+			total += hours[j].Score
+			j++
+		}
+		windows = append(windows, DeliveryWindow{
+			Start: hours[i].Time,
+			End:   hours[j-1].Time.Add(time.Hour),
+			Score: int(math.Round(float64(total) / float64(j-i))),
+		})
+		i = j
+	}
+	sort.SliceStable(windows, func(a, b int) bool { return windows[a].Score > windows[b].Score })
+	if len(windows) > deliveryMaxWindows {
+		windows = windows[:deliveryMaxWindows]
+	}
+	return windows
+}
+
+func (app *App) handleDeliveryWindow(w http.ResponseWriter, r *http.Request) {
+	date := r.URL.Query().Get("date")
+	if date != "" {
+		if _, err := time.Parse(time.DateOnly, date); err != nil {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{Message: "invalid date"})
+			return
+		}
+	}
+	cep, forecast, ok := app.forecastForRequest(w, r)
+	if !ok {
+		return
+	}
+	hours := scoreDeliveryHours(forecast.Hours, time.Now(), date)
+	if date != "" && len(hours) == 0 {
+		writeJSON(w, http.StatusUnprocessableEntity, ErrorResponse{Message: "date outside forecast range"})
+		return
+	}
+	writeJSON(w, http.StatusOK, DeliveryWindowResponse{
+		CEP:     cep,
+		Date:    date,
+		Windows: bestDeliveryWindows(hours),
+		Hours:   hours,
+	})
+}
+
+// forecastForRequest resolves the {cep} path variable and fetches its hourly
+// forecast. On failure it writes the error response and returns false.
+func (app *App) forecastForRequest(w http.ResponseWriter, r *http.Request) (string, *Forecast, bool) {
+	forecaster, ok := app.weather.(ForecastProvider)
+	if !ok {
+		writeJSON(w, http.StatusNotImplemented, ErrorResponse{Message: "forecast not available"})
+		return "", nil, false
+	}
+	ctx, cancel := app.budget.start(r.Context())
+	defer cancel()
+	normalizedCEP, cepInfo, ok := app.resolveAddress(ctx, w, r)
+	if !ok {
+		return "", nil, false
+	}
+	forecast, err := forecaster.LookupForecast(ctx, cepInfo, forecastDays)
+	switch {
+	case errors.Is(err, ErrForecastUnsupported):
+		writeJSON(w, http.StatusNotImplemented, ErrorResponse{Message: "forecast not available"})
+		return "", nil, false
+	case errors.Is(err, context.Canceled):
+		slog.InfoContext(ctx, "Client disconnected during forecast lookup", "cep", normalizedCEP)
+		return "", nil, false
+	case errors.Is(err, context.DeadlineExceeded):
+		writeJSON(w, http.StatusGatewayTimeout, ErrorResponse{Message: "upstream timeout"})
+		return "", nil, false
+	case errors.Is(err, ErrWeatherQuotaExceeded):
+		writeRetryAfter(w, app.weather.RetryAfter(err))
+		writeJSON(w, http.StatusServiceUnavailable, ErrorResponse{Message: "weather provider quota exceeded"})
+		return "", nil, false
+	case errors.Is(err, ErrCircuitOpen):
+		writeRetryAfter(w, app.weather.RetryAfter(err))
+		writeJSON(w, http.StatusServiceUnavailable, ErrorResponse{Message: "weather provider unavailable"})
+		return "", nil, false
+	case err != nil:
+		slog.ErrorContext(ctx, "Error getting forecast", "cep", normalizedCEP, "city", cepInfo.City, "error", redactURLError(err))
+		captureUpstreamError(ctx, err, app.weatherProvider, normalizedCEP, cepInfo.City)
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{Message: "error getting weather information"})
+		return "", nil, false
+	}
+	return normalizedCEP, forecast, true
+}
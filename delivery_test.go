@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDeliveryScore(t *testing.T) {
+	tests := []struct {
+		name     string
+		hour     HourlyForecast
+		expected int
+	}{
+		{"Tempo seco e ameno", HourlyForecast{TempC: 24}, 100},
+		{"Chance de chuva", HourlyForecast{TempC: 24, ChanceOfRain: 50}, 70},
+		{"Chuva forte", HourlyForecast{TempC: 24, ChanceOfRain: 100, PrecipMM: 8}, 0},
+		{"Calor extremo", HourlyForecast{TempC: 38}, 70},
+		{"Frio extremo", HourlyForecast{TempC: 2, ChanceOfRain: 10}, 64},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := deliveryScore(tt.hour); got != tt.expected {
+				t.Errorf("Expected score %d, got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestBestDeliveryWindows(t *testing.T) {
+	day := time.Date(2030, 1, 2, 0, 0, 0, 0, time.FixedZone("-03", -3*3600))
+	at := func(hour int) time.Time { return day.Add(time.Duration(hour) * time.Hour) }
+	forecast := []HourlyForecast{
+		{Time: at(6), TempC: 20},
+		{Time: at(8), TempC: 22},
+		{Time: at(9), TempC: 23, ChanceOfRain: 20},
+		{Time: at(10), TempC: 25, ChanceOfRain: 90, PrecipMM: 3},
+		{Time: at(11), TempC: 26},
+		{Time: at(20), TempC: 21},
+		{Time: day.AddDate(0, 0, 1).Add(9 * time.Hour), TempC: 24},
+	}
+
+	hours := scoreDeliveryHours(forecast, day, "2030-01-02")
+	if len(hours) != 4 {
+		t.Fatalf("Expected the 4 delivery hours of the date, got %d", len(hours))
+	}
+	if hours[2].Risk != "high" {
+		t.Errorf("Expected rainy hour to be high risk, got %s", hours[2].Risk)
+	}
+
+	windows := bestDeliveryWindows(hours)
+	if len(windows) != 2 {
+		t.Fatalf("Expected 2 windows, got %v", windows)
+	}
+	if !windows[0].Start.Equal(at(11)) || !windows[0].End.Equal(at(12)) || windows[0].Score != 100 {
+		t.Errorf("Unexpected best window %+v", windows[0])
+	}
+	if !windows[1].Start.Equal(at(8)) || !windows[1].End.Equal(at(10)) || windows[1].Score != 94 {
+		t.Errorf("Unexpected second window %+v", windows[1])
+	}
+
+	if hours := scoreDeliveryHours(forecast, at(10), ""); len(hours) != 3 || !hours[0].Time.Equal(at(10)) {
+		t.Errorf("Expected past hours to be dropped, got %v", hours)
+	}
+}
+
+func TestHandleDeliveryWindow(t *testing.T) {
+	zone := time.FixedZone("America/Sao_Paulo", -3*3600)
+	now := time.Now().In(zone)
+	tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, zone)
+	var hours []string
+	for h := 0; h < 24; h++ {
+		chance := 0
+		if h >= 12 {
+			chance = 90
+		}
+		hours = append(hours, fmt.Sprintf(`{"time_epoch": %d, "temp_c": 24, "chance_of_rain": %d}`, tomorrow.Add(time.Duration(h)*time.Hour).Unix(), chance))
+	}
+	weatherResponse := fmt.Sprintf(`{
+		"location": {"name": "São Paulo", "tz_id": "America/Sao_Paulo", "localtime_epoch": %d, "localtime": %q},
+		"current": {"temp_c": 24},
+		"forecast": {"forecastday": [{"hour": [%s]}]}
+	}`, now.Unix(), now.Format("2006-01-02 15:04"), strings.Join(hours, ","))
+
+	mockClient := NewMockHTTPClient()
+	app := NewApp(NewCEPService(mockClient), NewWeatherService(mockClient, "test-api-key"))
+	handler := app.setupRoutes()
+
+	date := tomorrow.Format(time.DateOnly)
+	tests := []struct {
+		name     string
+		path     string
+		expected int
+	}{
+		{"Data inválida", "/delivery-window/01310100?date=amanha", http.StatusBadRequest},
+		{"CEP inválido", "/delivery-window/123", http.StatusUnprocessableEntity},
+		{"Data fora da previsão", "/delivery-window/01310100?date=2000-01-01", http.StatusUnprocessableEntity},
+		{"Janelas do dia", "/delivery-window/01310100?date=" + date, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient.AddResponse("https://viacep.com.br/ws/01310100/json/", 200, `{"cep": "01310-100", "localidade": "São Paulo", "uf": "SP"}`)
+			mockClient.AddResponse("https://api.weatherapi.com/v1/forecast.json?key=test-api-key&q=Sao+Paulo%2CSP%2CBrazil&days=3&aqi=no&alerts=yes", 200, weatherResponse)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest("GET", tt.path, nil))
+			if rec.Code != tt.expected {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expected, rec.Code, rec.Body.String())
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+			var response DeliveryWindowResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Error parsing response: %v", err)
+			}
+			if len(response.Hours) != deliveryEndHour-deliveryStartHour {
+				t.Errorf("Expected %d delivery hours, got %d", deliveryEndHour-deliveryStartHour, len(response.Hours))
+			}
+			if len(response.Windows) != 1 || response.Windows[0].Start.Hour() != 8 || response.Windows[0].End.Hour() != 12 {
+				t.Errorf("Expected a single 08:00-12:00 window, got %+v", response.Windows)
+			}
+		})
+	}
+}
+
+func TestWeatherService_LookupForecastCache(t *testing.T) {
+	forecastURL := "https://api.weatherapi.com/v1/forecast.json?key=test-api-key&q=Sao+Paulo%2CSP%2CBrazil&days=3&aqi=no&alerts=yes"
+	mockClient := NewMockHTTPClient()
+	mockClient.AddResponse(forecastURL, 200, `{"forecast": {"forecastday": [{"hour": [{"time_epoch": 1700000000, "temp_c": 24}]}]}}`)
+	service := NewWeatherService(mockClient, "test-api-key")
+	service.forecasts = newLRUCache[*Forecast](10, time.Minute)
+
+	first, err := service.LookupForecast(context.Background(), &Address{CEP: "01310100", City: "São Paulo", State: "SP"}, forecastDays)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	// The mock's body is spent: a second upstream call would fail to decode.
+	second, err := service.LookupForecast(context.Background(), &Address{CEP: "01001000", City: "São Paulo", State: "SP"}, forecastDays)
+	if err != nil {
+		t.Fatalf("Expected the cached forecast, got %v", err)
+	}
+	if second != first {
+		t.Error("Expected every CEP of the municipality to share the cached forecast")
+	}
+}
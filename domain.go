@@ -0,0 +1,115 @@
+package main
+
+import (
+	"time"
+	"unsafe"
+)
+
+// Address and Observation are the service's own model. Provider DTOs
+// (ViaCEPResponse, WeatherAPIResponse) are mapped into them right after
+// decoding and must not leak past the services.
+
+type Address struct {
+	CEP          string `json:"cep"`
+	Street       string `json:"street"`
+	Complement   string `json:"complement"`
+	Neighborhood string `json:"neighborhood"`
+	City         string `json:"city"`
+	State        string `json:"state"`
+	IBGE         string `json:"ibge"`
+	GIA          string `json:"gia"`
+	DDD          string `json:"ddd"`
+	SIAFI        string `json:"siafi"`
+	Country      string `json:"country,omitempty"`
+}
+
+type Observation struct {
+	City         string    `json:"city"`
+	Region       string    `json:"region"`
+	Country      string    `json:"country"`
+	Lat          float64   `json:"lat"`
+	Lon          float64   `json:"lon"`
+	TempC        float64   `json:"temp_c"`
+	IsDay        bool      `json:"is_day"`
+	UV           float64   `json:"uv"`
+	ChanceOfRain *int      `json:"chance_of_rain,omitempty"`
+	Condition    string    `json:"condition"`
+	ObservedAt   time.Time `json:"observed_at"`
+}
+
+func (r *ViaCEPResponse) toAddress() *Address {
+	return &Address{
+		CEP:          normalizeCEP(r.CEP),
+		Street:       r.Logradouro,
+		Complement:   r.Complemento,
+		Neighborhood: r.Bairro,
+		City:         r.Localidade,
+		State:        r.UF,
+		IBGE:         r.IBGE,
+		GIA:          r.GIA,
+		DDD:          r.DDD,
+		SIAFI:        r.SIAFI,
+	}
+}
+
+func viaCEPResponseFromAddress(a *Address) *ViaCEPResponse {
+	cep := a.CEP
+	if len(cep) == 8 {
+		cep = cep[:5] + "-" + cep[5:]
+	}
+	return &ViaCEPResponse{
+		CEP:         cep,
+		Logradouro:  a.Street,
+		Complemento: a.Complement,
+		Bairro:      a.Neighborhood,
+		Localidade:  a.City,
+		UF:          a.State,
+		IBGE:        a.IBGE,
+		GIA:         a.GIA,
+		DDD:         a.DDD,
+		SIAFI:       a.SIAFI,
+	}
+}
+
+func (r *WeatherAPIResponse) toObservation() *Observation {
+	return &Observation{
+		City:         r.Location.Name,
+		Region:       r.Location.Region,
+		Country:      r.Location.Country,
+		Lat:          r.Location.Lat,
+		Lon:          r.Location.Lon,
+		TempC:        r.Current.TempC,
+		IsDay:        r.Current.IsDay == 1,
+		UV:           r.Current.UV,
+		ChanceOfRain: r.currentHourChanceOfRain(),
+		Condition:    conditionFromWeatherAPICode(r.Current.Condition.Code),
+		ObservedAt:   time.Unix(r.Current.LastUpdatedEpoch, 0).UTC(),
+	}
+}
+
+// currentHourChanceOfRain returns the hourly forecast's chance of rain for the
+// hour containing the observation, or nil when the forecast does not cover it.
+func (r *WeatherAPIResponse) currentHourChanceOfRain() *int {
+	now := r.Current.LastUpdatedEpoch
+	if now == 0 {
+		now = r.Location.LocaltimeEpoch
+	}
+	for _, day := range r.Forecast.Forecastday {
+		for _, hour := range day.Hour {
+			if now >= hour.TimeEpoch && now < hour.TimeEpoch+3600 {
+				chance := hour.ChanceOfRain
+				return &chance
+			}
+		}
+	}
+	return nil
+}
+
+func observationSize(o *Observation) int64 {
+	return int64(unsafe.Sizeof(*o)) + int64(len(o.City)+len(o.Region)+len(o.Country)+len(o.Condition))
+}
+
+func addressSize(a *Address) int64 {
+	return int64(unsafe.Sizeof(*a)) + int64(len(a.CEP)+len(a.Street)+len(a.Complement)+len(a.Neighborhood)+
+		len(a.City)+len(a.State)+len(a.IBGE)+len(a.GIA)+len(a.DDD)+len(a.SIAFI))
+}
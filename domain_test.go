@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestViaCEPResponseMapping(t *testing.T) {
+	dto := ViaCEPResponse{
+		CEP:        "01310-100",
+		Logradouro: "Avenida Paulista",
+		Bairro:     "Bela Vista",
+		Localidade: "São Paulo",
+		UF:         "SP",
+		IBGE:       "3550308",
+		GIA:        "1004",
+		DDD:        "11",
+		SIAFI:      "7107",
+	}
+
+	address := dto.toAddress()
+	if address.CEP != "01310100" {
+		t.Errorf("Expected normalized CEP '01310100', got '%s'", address.CEP)
+	}
+	if address.City != "São Paulo" || address.State != "SP" || address.Street != "Avenida Paulista" {
+		t.Errorf("Unexpected address mapping: %+v", address)
+	}
+
+	if roundTrip := viaCEPResponseFromAddress(address); *roundTrip != dto {
+		t.Errorf("Expected round trip %+v, got %+v", dto, *roundTrip)
+	}
+}
+
+func TestWeatherAPIResponseMapping(t *testing.T) {
+	var dto WeatherAPIResponse
+	dto.Location.Name = "São Paulo"
+	dto.Location.Lat = -23.55
+	dto.Location.Lon = -46.64
+	dto.Current.TempC = 25.0
+	dto.Current.IsDay = 1
+	dto.Current.LastUpdatedEpoch = 1700000000
+	dto.Current.Condition.Text = "Sunny"
+	dto.Current.Condition.Code = 1000
+
+	observation := dto.toObservation()
+	if observation.City != "São Paulo" || observation.TempC != 25.0 || !observation.IsDay {
+		t.Errorf("Unexpected observation mapping: %+v", observation)
+	}
+	if observation.Condition != "clear" {
+		t.Errorf("Unexpected condition mapping: %+v", observation)
+	}
+	if !observation.ObservedAt.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("Unexpected observation time: %s", observation.ObservedAt)
+	}
+}
+
+func TestCurrentHourChanceOfRain(t *testing.T) {
+	body := `{
+		"current": {"last_updated_epoch": 1700003700},
+		"forecast": {"forecastday": [{"hour": [
+			{"time_epoch": 1700000000, "chance_of_rain": 10},
+			{"time_epoch": 1700003600, "chance_of_rain": 85},
+			{"time_epoch": 1700007200, "chance_of_rain": 40}
+		]}]}
+	}`
+	var dto WeatherAPIResponse
+	if err := json.Unmarshal([]byte(body), &dto); err != nil {
+		t.Fatal(err)
+	}
+
+	chance := dto.toObservation().ChanceOfRain
+	if chance == nil || *chance != 85 {
+		t.Errorf("Expected chance of rain 85, got %v", chance)
+	}
+
+	dto.Current.LastUpdatedEpoch = 1600000000
+	if chance := dto.toObservation().ChanceOfRain; chance != nil {
+		t.Errorf("Expected no chance of rain outside the forecast, got %d", *chance)
+	}
+}
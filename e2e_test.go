@@ -0,0 +1,373 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeViaCEP emulates the ViaCEP API over HTTP: known CEPs return their
+// address, unknown ones {"erro": true}. Failure modes are toggled per test.
+type fakeViaCEP struct {
+	*httptest.Server
+	mu        sync.Mutex
+	addresses map[string]ViaCEPResponse
+	status    int
+	malformed bool
+	latency   time.Duration
+	calls     int32
+}
+
+func newFakeViaCEP(t *testing.T) *fakeViaCEP {
+	f := &fakeViaCEP{addresses: map[string]ViaCEPResponse{
+		"01310100": {CEP: "01310-100", Logradouro: "Avenida Paulista", Bairro: "Bela Vista", Localidade: "São Paulo", UF: "SP", IBGE: "3550308"},
+		"04538133": {CEP: "04538-133", Logradouro: "Avenida Brigadeiro Faria Lima", Bairro: "Itaim Bibi", Localidade: "São Paulo", UF: "SP", IBGE: "3550308"},
+		"20040002": {CEP: "20040-002", Logradouro: "Rua da Assembleia", Bairro: "Centro", Localidade: "Rio de Janeiro", UF: "RJ", IBGE: "3304557"},
+	}}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serveHTTP))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeViaCEP) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	atomic.AddInt32(&f.calls, 1)
+	cep := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/ws/"), "/json/")
+	f.mu.Lock()
+	status, malformed, latency := f.status, f.malformed, f.latency
+	address, known := f.addresses[cep]
+	f.mu.Unlock()
+	time.Sleep(latency)
+	if status != 0 {
+		w.WriteHeader(status)
+		return
+	}
+	if malformed {
+		fmt.Fprint(w, `{"cep": "0131`)
+		return
+	}
+	if !known {
+		json.NewEncoder(w).Encode(map[string]bool{"erro": true})
+		return
+	}
+	json.NewEncoder(w).Encode(address)
+}
+
+func (f *fakeViaCEP) set(fn func(f *fakeViaCEP)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fn(f)
+}
+
+// fakeWeatherAPI emulates WeatherAPI's forecast.json: it checks the API key,
+// resolves the "city,UF,Brazil" query against known temperatures and answers
+// with WeatherAPI's error envelope otherwise.
+type fakeWeatherAPI struct {
+	*httptest.Server
+	mu            sync.Mutex
+	apiKey        string
+	temperatures  map[string]float64
+	quotaExceeded bool
+	malformed     bool
+	status        int
+	latency       time.Duration
+	calls         int32
+}
+
+func newFakeWeatherAPI(t *testing.T, apiKey string) *fakeWeatherAPI {
+	f := &fakeWeatherAPI{apiKey: apiKey, temperatures: map[string]float64{
+		"Sao Paulo,SP":      22.5,
+		"Rio de Janeiro,RJ": 31.0,
+	}}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serveHTTP))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeWeatherAPI) writeError(w http.ResponseWriter, status, code int, message string) {
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `{"error": {"code": %d, "message": %q}}`, code, message)
+}
+
+func (f *fakeWeatherAPI) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	atomic.AddInt32(&f.calls, 1)
+	query := strings.TrimSuffix(r.URL.Query().Get("q"), ",Brazil")
+	f.mu.Lock()
+	status, malformed, quotaExceeded, latency := f.status, f.malformed, f.quotaExceeded, f.latency
+	tempC, known := f.temperatures[query]
+	f.mu.Unlock()
+	time.Sleep(latency)
+	switch {
+	case status != 0:
+		w.WriteHeader(status)
+		return
+	case r.URL.Query().Get("key") != f.apiKey:
+		f.writeError(w, http.StatusUnauthorized, 2006, "API key is invalid.")
+		return
+	case quotaExceeded:
+		f.writeError(w, http.StatusForbidden, 2007, "API key has exceeded calls per month quota.")
+		return
+	case malformed:
+		fmt.Fprint(w, `{"current": {"temp_c": `)
+		return
+	}
+	if !known {
+		f.writeError(w, http.StatusBadRequest, 1006, "No matching location found.")
+		return
+	}
+	now := time.Now().Unix()
+	city := strings.SplitN(query, ",", 2)[0]
+	fmt.Fprintf(w, `{
+		"location": {"name": %q, "country": "Brazil", "lat": -23.55, "lon": -46.64, "localtime_epoch": %d},
+		"current": {"last_updated_epoch": %d, "temp_c": %.1f, "is_day": 1, "uv": 6, "condition": {"text": "Partly cloudy", "code": 1003}},
+		"forecast": {"forecastday": [{"hour": [{"time_epoch": %d, "chance_of_rain": 30}]}]}
+	}`, city, now, now, tempC, now-now%3600)
+}
+
+func (f *fakeWeatherAPI) set(fn func(f *fakeWeatherAPI)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fn(f)
+}
+
+type e2eEnv struct {
+	viaCEP     *fakeViaCEP
+	weatherAPI *fakeWeatherAPI
+	app        *App
+	server     *httptest.Server
+}
+
+// newE2EEnv boots the application through buildApp, exactly as main does,
+// with a real HTTP client pointed at the upstream doubles.
+func newE2EEnv(t *testing.T, configure func(cfg *Config)) *e2eEnv {
+	t.Helper()
+	env := &e2eEnv{
+		viaCEP:     newFakeViaCEP(t),
+		weatherAPI: newFakeWeatherAPI(t, "e2e-key"),
+	}
+	cfg := Config{
+		WeatherAPIKey:          "e2e-key",
+		ViaCEPBaseURLs:         []string{env.viaCEP.URL},
+		WeatherAPIBaseURLs:     []string{env.weatherAPI.URL},
+		EndpointHealthInterval: time.Minute,
+		WeatherCacheSize:       100,
+		WeatherCacheTTL:        time.Minute,
+	}
+	if configure != nil {
+		configure(&cfg)
+	}
+	app, lifecycle := buildApp(cfg, &http.Client{Timeout: 2 * time.Second})
+	if err := lifecycle.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { lifecycle.Stop(context.Background()) })
+	env.app = app
+	env.server = httptest.NewServer(app.setupRoutes())
+	t.Cleanup(env.server.Close)
+	return env
+}
+
+func (env *e2eEnv) get(t *testing.T, path string, headers map[string]string) (*http.Response, []byte) {
+	t.Helper()
+	req, err := http.NewRequest("GET", env.server.URL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, body
+}
+
+func expectMessage(t *testing.T, body []byte, expected string) {
+	t.Helper()
+	var response ErrorResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		t.Fatalf("Error parsing response %q: %v", body, err)
+	}
+	if response.Message != expected {
+		t.Errorf("Expected message '%s', got '%s'", expected, response.Message)
+	}
+}
+
+func TestE2E_WeatherLookup(t *testing.T) {
+	env := newE2EEnv(t, nil)
+
+	resp, body := env.get(t, "/weather/01310-100", map[string]string{"Accept-Language": "en"})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", resp.StatusCode, body)
+	}
+	var response TemperatureResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		t.Fatalf("Error parsing response: %v", err)
+	}
+	if response.TempC != 22.5 || response.TempF != 72.5 || response.TempK != 295.5 {
+		t.Errorf("Unexpected temperatures: %+v", response)
+	}
+	if response.Condition == nil || response.Condition.Text != "Partly cloudy" {
+		t.Errorf("Unexpected condition: %+v", response.Condition)
+	}
+	if response.ChanceOfRain == nil || *response.ChanceOfRain != 30 {
+		t.Errorf("Expected chance_of_rain 30, got %v", response.ChanceOfRain)
+	}
+}
+
+func TestE2E_ErrorResponses(t *testing.T) {
+	tests := []struct {
+		name            string
+		path            string
+		setup           func(env *e2eEnv)
+		expectedStatus  int
+		expectedMessage string
+	}{
+		{"CEP inválido", "/weather/123", nil, http.StatusUnprocessableEntity, "invalid zipcode"},
+		{"CEP inexistente", "/weather/99999999", nil, http.StatusNotFound, "can not find zipcode"},
+		{"Corpo malformado da ViaCEP", "/weather/01310100", func(env *e2eEnv) {
+			env.viaCEP.set(func(f *fakeViaCEP) { f.malformed = true })
+		}, http.StatusNotFound, "can not find zipcode"},
+		{"Localidade desconhecida pela WeatherAPI", "/weather/01310100", func(env *e2eEnv) {
+			env.weatherAPI.set(func(f *fakeWeatherAPI) { f.temperatures = map[string]float64{} })
+		}, http.StatusInternalServerError, "error getting weather information"},
+		{"Corpo malformado da WeatherAPI", "/weather/01310100", func(env *e2eEnv) {
+			env.weatherAPI.set(func(f *fakeWeatherAPI) { f.malformed = true })
+		}, http.StatusInternalServerError, "error getting weather information"},
+		{"WeatherAPI indisponível", "/weather/01310100", func(env *e2eEnv) {
+			env.weatherAPI.set(func(f *fakeWeatherAPI) { f.status = http.StatusBadGateway })
+		}, http.StatusInternalServerError, "error getting weather information"},
+		{"Cota da WeatherAPI esgotada", "/weather/01310100", func(env *e2eEnv) {
+			env.weatherAPI.set(func(f *fakeWeatherAPI) { f.quotaExceeded = true })
+		}, http.StatusServiceUnavailable, "weather provider quota exceeded"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newE2EEnv(t, nil)
+			if tt.setup != nil {
+				tt.setup(env)
+			}
+			resp, body := env.get(t, tt.path, nil)
+			if resp.StatusCode != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, resp.StatusCode, body)
+			}
+			expectMessage(t, body, tt.expectedMessage)
+		})
+	}
+}
+
+func TestE2E_WeatherCache(t *testing.T) {
+	env := newE2EEnv(t, nil)
+	env.weatherAPI.set(func(f *fakeWeatherAPI) { f.latency = 50 * time.Millisecond })
+
+	resp, _ := env.get(t, "/weather/01310100", nil)
+	if resp.Header.Get("X-Cache") != "MISS" {
+		t.Errorf("Expected X-Cache MISS, got %s", resp.Header.Get("X-Cache"))
+	}
+
+	start := time.Now()
+	resp, _ = env.get(t, "/weather/04538133", nil)
+	if resp.Header.Get("X-Cache") != "HIT" {
+		t.Errorf("Expected X-Cache HIT for the same municipality, got %s", resp.Header.Get("X-Cache"))
+	}
+	if elapsed := time.Since(start); elapsed >= 50*time.Millisecond {
+		t.Errorf("Expected cached lookup to skip WeatherAPI latency, took %s", elapsed)
+	}
+	if calls := atomic.LoadInt32(&env.weatherAPI.calls); calls != 1 {
+		t.Errorf("Expected 1 WeatherAPI call, got %d", calls)
+	}
+
+	env.get(t, "/weather/20040002", nil)
+	if calls := atomic.LoadInt32(&env.weatherAPI.calls); calls != 2 {
+		t.Errorf("Expected a new WeatherAPI call for another municipality, got %d", calls)
+	}
+}
+
+func TestE2E_EndpointFailover(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	env := newE2EEnv(t, func(cfg *Config) {
+		cfg.ViaCEPBaseURLs = append([]string{down.URL}, cfg.ViaCEPBaseURLs...)
+	})
+	if resp, _ := env.get(t, "/weather/01310100", nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected first lookup against the unreachable endpoint to fail, got %d", resp.StatusCode)
+	}
+	resp, body := env.get(t, "/weather/01310100", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected lookup to fail over to the mirror, got %d: %s", resp.StatusCode, body)
+	}
+}
+
+func TestE2E_StaleWhileRevalidate(t *testing.T) {
+	env := newE2EEnv(t, func(cfg *Config) {
+		cfg.WeatherCacheTTL = 50 * time.Millisecond
+		cfg.WeatherCacheStaleTTL = time.Minute
+	})
+
+	env.get(t, "/weather/01310100", nil)
+	time.Sleep(60 * time.Millisecond)
+	env.weatherAPI.set(func(f *fakeWeatherAPI) {
+		f.temperatures["Sao Paulo,SP"] = 18.0
+		f.latency = 100 * time.Millisecond
+	})
+
+	start := time.Now()
+	resp, body := env.get(t, "/weather/01310100", nil)
+	if resp.Header.Get("X-Cache") != "STALE" {
+		t.Errorf("Expected X-Cache STALE, got %s", resp.Header.Get("X-Cache"))
+	}
+	if elapsed := time.Since(start); elapsed >= 100*time.Millisecond {
+		t.Errorf("Expected stale entry to be served without waiting for WeatherAPI, took %s", elapsed)
+	}
+	var response TemperatureResponse
+	json.Unmarshal(body, &response)
+	if response.TempC != 22.5 {
+		t.Errorf("Expected stale temperature 22.5, got %.1f", response.TempC)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		resp, body = env.get(t, "/weather/01310100", nil)
+		if resp.Header.Get("X-Cache") == "HIT" {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	json.Unmarshal(body, &response)
+	if resp.Header.Get("X-Cache") != "HIT" || response.TempC != 18.0 {
+		t.Errorf("Expected refreshed entry with 18.0, got %s with %.1f", resp.Header.Get("X-Cache"), response.TempC)
+	}
+}
+
+func TestE2E_StaleEntryHidesUpstreamOutage(t *testing.T) {
+	env := newE2EEnv(t, func(cfg *Config) {
+		cfg.WeatherCacheTTL = 20 * time.Millisecond
+		cfg.WeatherCacheStaleTTL = time.Minute
+	})
+
+	env.get(t, "/weather/01310100", nil)
+	time.Sleep(30 * time.Millisecond)
+	env.weatherAPI.set(func(f *fakeWeatherAPI) { f.status = http.StatusServiceUnavailable })
+
+	for i := 0; i < 3; i++ {
+		resp, _ := env.get(t, "/weather/01310100", nil)
+		if resp.StatusCode != http.StatusOK || resp.Header.Get("X-Cache") != "STALE" {
+			t.Errorf("Expected stale 200 during outage, got %d (X-Cache %s)", resp.StatusCode, resp.Header.Get("X-Cache"))
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultViaCEPBaseURL     = "https://viacep.com.br"
+	defaultWeatherAPIBaseURL = "https://api.weatherapi.com/v1"
+	viaCEPProbePath          = "/ws/01001000/json/"
+	weatherAPIProbePath      = "/current.json"
+)
+
+// EndpointPool holds the alternative base URLs configured for a provider and
+// picks the first one whose last health check succeeded.
+type EndpointPool struct {
+	mu        sync.RWMutex
+	client    HTTPClient
+	urls      []string
+	healthy   []bool
+	probePath string
+}
+
+func NewEndpointPool(client HTTPClient, probePath string, urls ...string) *EndpointPool {
+	pool := &EndpointPool{client: client, probePath: probePath}
+	for _, u := range urls {
+		u = strings.TrimRight(strings.TrimSpace(u), "/")
+		if u != "" {
+			pool.urls = append(pool.urls, u)
+			pool.healthy = append(pool.healthy, true)
+		}
+	}
+	return pool
+}
+
+func parseBaseURLs(raw string, fallback string) []string {
+	var urls []string
+	for _, u := range strings.Split(raw, ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			urls = append(urls, u)
+		}
+	}
+	if len(urls) == 0 {
+		return []string{fallback}
+	}
+	return urls
+}
+
+func (p *EndpointPool) Current() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for i, ok := range p.healthy {
+		if ok {
+			return p.urls[i]
+		}
+	}
+	return p.urls[0]
+}
+
+// Alternate returns a healthy endpoint other than baseURL, or "" when there
+// is none.
+func (p *EndpointPool) Alternate(baseURL string) string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for i, ok := range p.healthy {
+		if ok && p.urls[i] != baseURL {
+			return p.urls[i]
+		}
+	}
+	return ""
+}
+
+func (p *EndpointPool) MarkUnhealthy(baseURL string) {
+	p.setHealth(baseURL, false)
+}
+
+func (p *EndpointPool) setHealth(baseURL string, healthy bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, u := range p.urls {
+		if u == baseURL && p.healthy[i] != healthy {
+			p.healthy[i] = healthy
+			slog.Info("Endpoint health changed", "endpoint", baseURL, "healthy", healthy)
+		}
+	}
+}
+
+func (p *EndpointPool) CheckHealth() {
+	p.mu.RLock()
+	urls := append([]string(nil), p.urls...)
+	p.mu.RUnlock()
+	for _, u := range urls {
+		req, err := http.NewRequest(http.MethodGet, u+p.probePath, nil)
+		if err != nil {
+			p.setHealth(u, false)
+			continue
+		}
+		resp, err := p.client.Do(req)
+		if err != nil {
+			p.setHealth(u, false)
+			continue
+		}
+		resp.Body.Close()
+		p.setHealth(u, resp.StatusCode < http.StatusInternalServerError)
+	}
+}
+
+func (p *EndpointPool) Run(interval time.Duration, stop <-chan struct{}) {
+	if len(p.urls) < 2 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.CheckHealth()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// component runs the periodic health checks under the lifecycle manager.
+func (p *EndpointPool) component(name string, interval time.Duration) Component {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	return Component{
+		Name: name,
+		Start: func(ctx context.Context) error {
+			go func() {
+				defer close(done)
+				p.Run(interval, stop)
+			}()
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			close(stop)
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	}
+}
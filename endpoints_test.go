@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestParseBaseURLs(t *testing.T) {
+	urls := parseBaseURLs(" https://mirror.local/viacep , https://viacep.com.br ,", defaultViaCEPBaseURL)
+	if len(urls) != 2 || urls[0] != "https://mirror.local/viacep" {
+		t.Errorf("Unexpected URLs: %v", urls)
+	}
+
+	urls = parseBaseURLs("", defaultViaCEPBaseURL)
+	if len(urls) != 1 || urls[0] != defaultViaCEPBaseURL {
+		t.Errorf("Expected default URL, got %v", urls)
+	}
+}
+
+func TestEndpointPool(t *testing.T) {
+	t.Run("Seleciona o primeiro endpoint saudável", func(t *testing.T) {
+		mockClient := NewMockHTTPClient()
+		mockClient.AddError("https://primary.local/ws/01001000/json/", errors.New("connection refused"))
+		mockClient.AddResponse("https://mirror.local/ws/01001000/json/", 200, `{}`)
+		pool := NewEndpointPool(mockClient, viaCEPProbePath, "https://primary.local/", "https://mirror.local")
+
+		if current := pool.Current(); current != "https://primary.local" {
+			t.Errorf("Expected primary endpoint before health check, got %s", current)
+		}
+
+		pool.CheckHealth()
+
+		if current := pool.Current(); current != "https://mirror.local" {
+			t.Errorf("Expected mirror endpoint after health check, got %s", current)
+		}
+	})
+
+	t.Run("Endpoint volta a ser usado após recuperação", func(t *testing.T) {
+		mockClient := NewMockHTTPClient()
+		mockClient.AddResponse("https://primary.local/ws/01001000/json/", 200, `{}`)
+		pool := NewEndpointPool(mockClient, viaCEPProbePath, "https://primary.local", "https://mirror.local")
+
+		pool.MarkUnhealthy("https://primary.local")
+		if current := pool.Current(); current != "https://mirror.local" {
+			t.Errorf("Expected mirror endpoint, got %s", current)
+		}
+
+		pool.CheckHealth()
+		if current := pool.Current(); current != "https://primary.local" {
+			t.Errorf("Expected primary endpoint after recovery, got %s", current)
+		}
+	})
+
+	t.Run("Alternativa ignora o endpoint atual e os indisponíveis", func(t *testing.T) {
+		pool := NewEndpointPool(NewMockHTTPClient(), viaCEPProbePath, "https://primary.local", "https://mirror.local", "https://backup.local")
+		if alternate := pool.Alternate("https://primary.local"); alternate != "https://mirror.local" {
+			t.Errorf("Expected mirror as alternate, got %s", alternate)
+		}
+		pool.MarkUnhealthy("https://mirror.local")
+		pool.MarkUnhealthy("https://backup.local")
+		if alternate := pool.Alternate("https://primary.local"); alternate != "" {
+			t.Errorf("Expected no alternate, got %s", alternate)
+		}
+	})
+
+	t.Run("Sem endpoints saudáveis usa o primeiro", func(t *testing.T) {
+		pool := NewEndpointPool(NewMockHTTPClient(), viaCEPProbePath, "https://primary.local", "https://mirror.local")
+		pool.MarkUnhealthy("https://primary.local")
+		pool.MarkUnhealthy("https://mirror.local")
+
+		if current := pool.Current(); current != "https://primary.local" {
+			t.Errorf("Expected primary endpoint as last resort, got %s", current)
+		}
+	})
+}
+
+func TestCEPService_UsesConfiguredBaseURL(t *testing.T) {
+	mockClient := NewMockHTTPClient()
+	mockClient.AddResponse("https://mirror.local/ws/01310100/json/", 200, `{"localidade": "São Paulo", "uf": "SP"}`)
+	service := NewCEPService(mockClient, "https://mirror.local")
+
+	result, err := service.GetCEPInfo(context.Background(), "01310100")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.State != "SP" {
+		t.Errorf("Expected state 'SP', got '%s'", result.State)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultEnergyBaseTempC        = 18.0
+	defaultEnergyLoadPerDegreeDay = 0.05
+)
+
+// EnergyPolicy holds the degree-day coefficients: cooling degree days are
+// counted above BaseTempC and each one adds LoadPerDegreeDay to the
+// relative load index, where 1 is the load of a day at or below the base.
+type EnergyPolicy struct {
+	BaseTempC        float64
+	LoadPerDegreeDay float64
+}
+
+type EnergyDay struct {
+	Date              string  `json:"date"`
+	MeanTempC         float64 `json:"mean_temp_C"`
+	MaxTempC          float64 `json:"max_temp_C"`
+	CoolingDegreeDays float64 `json:"cooling_degree_days"`
+	LoadIndex         float64 `json:"load_index"`
+}
+
+type EnergyResponse struct {
+	CEP       string      `json:"cep"`
+	BaseTempC float64     `json:"base_temp_C"`
+	Days      []EnergyDay `json:"days"`
+}
+
+func roundTo(v float64, decimals int) float64 {
+	p := math.Pow(10, float64(decimals))
+	return math.Round(v*p) / p
+}
+
+// coolingLoad aggregates the hourly forecast per local day. The daily mean
+// uses every forecast hour of the day, past ones included.
+func coolingLoad(forecast []HourlyForecast, policy EnergyPolicy) []EnergyDay {
+	days := []EnergyDay{}
+	var sum float64
+	var hours int
+	for i, hour := range forecast {
+		date := hour.Time.Format(time.DateOnly)
+		if hours == 0 {
+			days = append(days, EnergyDay{Date: date, MaxTempC: hour.TempC})
+		}
+		day := &days[len(days)-1]
+		sum += hour.TempC
+		hours++
+		day.MaxTempC = math.Max(day.MaxTempC, hour.TempC)
+		if i+1 < len(forecast) && forecast[i+1].Time.Format(time.DateOnly) == date {
+			continue
+		}
+		mean := sum / float64(hours)
+		degreeDays := math.Max(0, mean-policy.BaseTempC)
+		day.MeanTempC = roundTo(mean, 1)
+		day.CoolingDegreeDays = roundTo(degreeDays, 1)
+		day.LoadIndex = roundTo(1+degreeDays*policy.LoadPerDegreeDay, 2)
+		sum, hours = 0, 0
+	}
+	return days
+}
+
+func (app *App) handleEnergy(w http.ResponseWriter, r *http.Request) {
+	cep, forecast, ok := app.forecastForRequest(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, EnergyResponse{
+		CEP:       cep,
+		BaseTempC: app.energy.BaseTempC,
+		Days:      coolingLoad(forecast.Hours, app.energy),
+	})
+}
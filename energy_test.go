@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCoolingLoad(t *testing.T) {
+	zone := time.FixedZone("-03", -3*3600)
+	day := func(d, h int) time.Time { return time.Date(2030, 1, d, h, 0, 0, 0, zone) }
+	forecast := []HourlyForecast{
+		{Time: day(1, 0), TempC: 14},
+		{Time: day(1, 12), TempC: 20},
+		{Time: day(2, 0), TempC: 24},
+		{Time: day(2, 12), TempC: 34},
+	}
+
+	tests := []struct {
+		name     string
+		policy   EnergyPolicy
+		expected []EnergyDay
+	}{
+		{"Coeficientes padrão", EnergyPolicy{BaseTempC: 18, LoadPerDegreeDay: 0.05}, []EnergyDay{
+			{Date: "2030-01-01", MeanTempC: 17, MaxTempC: 20, CoolingDegreeDays: 0, LoadIndex: 1},
+			{Date: "2030-01-02", MeanTempC: 29, MaxTempC: 34, CoolingDegreeDays: 11, LoadIndex: 1.55},
+		}},
+		{"Base mais baixa", EnergyPolicy{BaseTempC: 15, LoadPerDegreeDay: 0.1}, []EnergyDay{
+			{Date: "2030-01-01", MeanTempC: 17, MaxTempC: 20, CoolingDegreeDays: 2, LoadIndex: 1.2},
+			{Date: "2030-01-02", MeanTempC: 29, MaxTempC: 34, CoolingDegreeDays: 14, LoadIndex: 2.4},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := coolingLoad(forecast, tt.policy)
+			if fmt.Sprint(got) != fmt.Sprint(tt.expected) {
+				t.Errorf("Expected %+v, got %+v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestHandleEnergy(t *testing.T) {
+	start := time.Now().UTC().Truncate(24 * time.Hour)
+	var hours []string
+	for h := 0; h < 48; h++ {
+		hours = append(hours, fmt.Sprintf(`{"time_epoch": %d, "temp_c": 28}`, start.Add(time.Duration(h)*time.Hour).Unix()))
+	}
+	weatherResponse := fmt.Sprintf(`{"location": {"name": "São Paulo"}, "forecast": {"forecastday": [{"hour": [%s]}]}}`, strings.Join(hours, ","))
+
+	mockClient := NewMockHTTPClient()
+	mockClient.AddResponse("https://viacep.com.br/ws/01310100/json/", 200, `{"cep": "01310-100", "localidade": "São Paulo", "uf": "SP"}`)
+	mockClient.AddResponse("https://api.weatherapi.com/v1/forecast.json?key=test-api-key&q=Sao+Paulo%2CSP%2CBrazil&days=3&aqi=no&alerts=yes", 200, weatherResponse)
+	app := NewApp(NewCEPService(mockClient), NewWeatherService(mockClient, "test-api-key"))
+
+	rec := httptest.NewRecorder()
+	app.setupRoutes().ServeHTTP(rec, httptest.NewRequest("GET", "/energy/01310100", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var response EnergyResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Error parsing response: %v", err)
+	}
+	if len(response.Days) != 2 || response.Days[0].CoolingDegreeDays != 10 || response.Days[0].LoadIndex != 1.5 {
+		t.Errorf("Unexpected response %+v", response)
+	}
+}
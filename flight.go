@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// doShared runs fn once per key for all concurrent callers. The shared call
+// runs with the context of the caller that started it, so each caller waits
+// only as long as its own context allows. If the starting caller goes away,
+// the others re-enter the group: the first to do so starts a new call with
+// its own context and the rest share it, instead of each calling upstream.
+func doShared[T any](ctx context.Context, group *singleflight.Group, key string, fn func(context.Context) (T, error)) (T, error) {
+	var zero T
+	for {
+		led := false
+		ch := group.DoChan(key, func() (interface{}, error) {
+			led = true
+			return fn(ctx)
+		})
+		select {
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		case result := <-ch:
+			if result.Err != nil {
+				// singleflight has already dropped the finished call, so no
+				// Forget is needed; calling it here could drop the call
+				// another waiter has just started.
+				if !led && ctx.Err() == nil && (errors.Is(result.Err, context.Canceled) || errors.Is(result.Err, context.DeadlineExceeded)) {
+					continue
+				}
+				return zero, result.Err
+			}
+			return result.Val.(T), nil
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+const geoJSONContentType = "application/geo+json"
+
+type GeoJSONGeometry struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"`
+}
+
+type GeoJSONProperties struct {
+	CEP           string  `json:"cep"`
+	City          string  `json:"city"`
+	State         string  `json:"state"`
+	ConditionCode string  `json:"condition_code"`
+	Condition     string  `json:"condition"`
+	TempC         float64 `json:"temp_C"`
+	TempF         float64 `json:"temp_F"`
+	TempK         float64 `json:"temp_K"`
+}
+
+type GeoJSONFeature struct {
+	Type       string            `json:"type"`
+	Geometry   GeoJSONGeometry   `json:"geometry"`
+	Properties GeoJSONProperties `json:"properties"`
+}
+
+func wantsGeoJSON(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), geoJSONContentType)
+}
+
+// newGeoJSONFeature builds a Point feature at the location the weather
+// provider resolved. GeoJSON positions are [longitude, latitude].
+func newGeoJSONFeature(address *Address, observation *Observation, temperature TemperatureResponse) GeoJSONFeature {
+	var conditionText string
+	if temperature.Condition != nil {
+		conditionText = temperature.Condition.Text
+	}
+	return GeoJSONFeature{
+		Type: "Feature",
+		Geometry: GeoJSONGeometry{
+			Type:        "Point",
+			Coordinates: []float64{observation.Lon, observation.Lat},
+		},
+		Properties: GeoJSONProperties{
+			CEP:           address.CEP,
+			City:          address.City,
+			State:         address.State,
+			ConditionCode: observation.Condition,
+			Condition:     conditionText,
+			TempC:         temperature.TempC,
+			TempF:         temperature.TempF,
+			TempK:         temperature.TempK,
+		},
+	}
+}
+
+func writeGeoJSON(w http.ResponseWriter, status int, feature GeoJSONFeature) {
+	w.Header().Set("Content-Type", geoJSONContentType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(feature)
+}
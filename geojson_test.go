@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandleWeatherByCEP_GeoJSON(t *testing.T) {
+	mockClient := NewMockHTTPClient()
+	app := NewApp(NewCEPService(mockClient), NewWeatherService(mockClient, "test-api-key"))
+	mockClient.AddResponse("https://viacep.com.br/ws/01310100/json/", 200, `{"cep": "01310-100", "localidade": "São Paulo", "uf": "SP"}`)
+	mockClient.AddResponse("https://api.weatherapi.com/v1/forecast.json?key=test-api-key&q=Sao+Paulo%2CSP%2CBrazil&days=1&aqi=no&alerts=no", 200,
+		`{"location": {"name": "Sao Paulo", "lat": -23.53, "lon": -46.62}, "current": {"temp_c": 25.0, "condition": {"text": "Sunny", "code": 1000}}}`)
+
+	req := httptest.NewRequest("GET", "/weather/01310100", nil)
+	req.Header.Set("Accept", "application/geo+json")
+	rr := httptest.NewRecorder()
+	app.setupRoutes().ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+	if contentType := rr.Header().Get("Content-Type"); contentType != "application/geo+json" {
+		t.Errorf("Expected Content-Type application/geo+json, got %s", contentType)
+	}
+
+	var feature GeoJSONFeature
+	if err := json.Unmarshal(rr.Body.Bytes(), &feature); err != nil {
+		t.Fatalf("Error parsing response: %v", err)
+	}
+	if feature.Type != "Feature" || feature.Geometry.Type != "Point" {
+		t.Errorf("Unexpected feature: %+v", feature)
+	}
+	if len(feature.Geometry.Coordinates) != 2 || feature.Geometry.Coordinates[0] != -46.62 || feature.Geometry.Coordinates[1] != -23.53 {
+		t.Errorf("Expected coordinates [-46.62, -23.53], got %v", feature.Geometry.Coordinates)
+	}
+	if feature.Properties.CEP != "01310100" || feature.Properties.TempK != 298.0 {
+		t.Errorf("Unexpected properties: %+v", feature.Properties)
+	}
+}
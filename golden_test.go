@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite golden files with the current responses")
+
+// TestGoldenResponses pins the full response contract (status, content type
+// and body) of every endpoint/format combination. Run with -update after an
+// intentional change and review the diff under testdata/golden.
+func TestGoldenResponses(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		headers map[string]string
+		setup   func(env *e2eEnv)
+	}{
+		{name: "weather_json", path: "/weather/01310100"},
+		{name: "weather_json_en", path: "/weather/01310100?lang=en"},
+		{name: "weather_json_es", path: "/weather/01310100", headers: map[string]string{"Accept-Language": "es-AR"}},
+		{name: "weather_geojson", path: "/weather/01310100", headers: map[string]string{"Accept": "application/geo+json"}},
+		{name: "weather_invalid_zipcode", path: "/weather/123"},
+		{name: "weather_zipcode_not_found", path: "/weather/99999999"},
+		{name: "weather_upstream_error", path: "/weather/01310100", setup: func(env *e2eEnv) {
+			env.weatherAPI.set(func(f *fakeWeatherAPI) { f.status = http.StatusBadGateway })
+		}},
+		{name: "weather_quota_exceeded", path: "/weather/01310100", setup: func(env *e2eEnv) {
+			env.weatherAPI.set(func(f *fakeWeatherAPI) { f.quotaExceeded = true })
+		}},
+		{name: "weather_invalid_client_tag", path: "/weather/01310100", headers: map[string]string{"X-Client-Tag": "not valid"}},
+		{name: "proxy_viacep", path: "/proxy/viacep/01310100"},
+		{name: "proxy_viacep_not_found", path: "/proxy/viacep/99999999"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newE2EEnv(t, func(cfg *Config) { cfg.ViaCEPProxyEnabled = true })
+			if tt.setup != nil {
+				tt.setup(env)
+			}
+			headers := map[string]string{requestIDHeader: "golden"}
+			for k, v := range tt.headers {
+				headers[k] = v
+			}
+			resp, body := env.get(t, tt.path, headers)
+			actual := []byte(fmt.Sprintf("HTTP %d\nContent-Type: %s\n\n%s", resp.StatusCode, resp.Header.Get("Content-Type"), body))
+
+			golden := filepath.Join("testdata", "golden", tt.name+".golden")
+			if *updateGolden {
+				if err := os.MkdirAll(filepath.Dir(golden), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(golden, actual, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			expected, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("Error reading golden file (run with -update to create it): %v", err)
+			}
+			if !bytes.Equal(actual, expected) {
+				t.Errorf("Response does not match %s\n--- expected\n%s\n--- actual\n%s", golden, expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	deepHealthTimeout = 5 * time.Second
+	// deepHealthCacheTTL bounds how often the endpoint reaches upstreams, so
+	// a tight polling loop does not spend the WeatherAPI quota.
+	deepHealthCacheTTL = 10 * time.Second
+)
+
+type dependencyCheck struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+type DependencyStatus struct {
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+type DeepHealthResponse struct {
+	Status       string             `json:"status"`
+	CheckedAt    time.Time          `json:"checked_at"`
+	Dependencies []DependencyStatus `json:"dependencies"`
+}
+
+// deepHealth runs a real call against every dependency, in parallel, and
+// keeps the result for deepHealthCacheTTL.
+type deepHealth struct {
+	checks []dependencyCheck
+	mu     sync.Mutex
+	last   *DeepHealthResponse
+	now    func() time.Time
+}
+
+func newDeepHealth() *deepHealth {
+	return &deepHealth{now: time.Now}
+}
+
+func (h *deepHealth) add(name string, check func(ctx context.Context) error) {
+	h.checks = append(h.checks, dependencyCheck{name: name, check: check})
+}
+
+func (h *deepHealth) run(ctx context.Context) DeepHealthResponse {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.last != nil && h.now().Sub(h.last.CheckedAt) < deepHealthCacheTTL {
+		return *h.last
+	}
+	ctx, cancel := context.WithTimeout(ctx, deepHealthTimeout)
+	defer cancel()
+	response := DeepHealthResponse{Status: "ok", CheckedAt: h.now(), Dependencies: make([]DependencyStatus, len(h.checks))}
+	var wg sync.WaitGroup
+	for i, c := range h.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			err := c.check(ctx)
+			status := DependencyStatus{Name: c.name, Status: "ok", LatencyMs: time.Since(start).Milliseconds()}
+			if err != nil {
+				status.Status = "fail"
+				status.Error = dependencyError(err)
+			}
+			response.Dependencies[i] = status
+		}()
+	}
+	wg.Wait()
+	for _, dep := range response.Dependencies {
+		if dep.Status != "ok" {
+			response.Status = "degraded"
+		}
+	}
+	// A canceled caller says nothing about the dependencies.
+	if ctx.Err() != context.Canceled {
+		h.last = &response
+	}
+	return response
+}
+
+// dependencyError describes a failed check without quoting the request
+// URL, which for WeatherAPI carries the key.
+func dependencyError(err error) string {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return "timeout"
+	}
+	return redactURLError(err).Error()
+}
+
+// registerDependencyChecks probes the same upstreams `check -probe` does:
+// the current ViaCEP endpoint, the weather source (which validates the
+// WeatherAPI key) and Redis when configured. Like `check -probe`, the probes
+// use services of their own, without the live circuit breakers and
+// retries: a failing poll must not trip a breaker serving traffic, and an
+// open breaker must not hide the dependency's actual state.
+func registerDependencyChecks(h *deepHealth, cfg Config, client HTTPClient, cepService *CEPService, weatherService *WeatherService, weatherProvider string, redisClient *redis.Client) {
+	h.add("viacep", func(ctx context.Context) error {
+		probe := NewCEPService(client, cepService.endpoints.Current())
+		// Not found still proves ViaCEP answered.
+		if _, err := probe.fetchCEPInfo(ctx, checkProbeCEP); err != nil && !errors.Is(err, ErrCEPNotFound) {
+			return err
+		}
+		return nil
+	})
+	h.add(weatherProvider, func(ctx context.Context) error {
+		if weatherService.source != nil {
+			_, err := NewOpenMeteoSource(client, cfg.OpenMeteoGeocodingURL, cfg.OpenMeteoForecastURL).Fetch(ctx, checkProbeLocation)
+			return err
+		}
+		_, err := NewWeatherService(client, cfg.WeatherAPIKey, weatherService.endpoints.Current()).fetch(ctx, checkProbeLocation)
+		return err
+	})
+	if redisClient != nil {
+		h.add("redis", func(ctx context.Context) error {
+			return redisClient.Ping(ctx).Err()
+		})
+	}
+}
+
+func (app *App) handleDeepHealth(w http.ResponseWriter, r *http.Request) {
+	response := app.health.run(r.Context())
+	status := http.StatusOK
+	if response.Status != "ok" {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, response)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	neturl "net/url"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestDeepHealth_Run(t *testing.T) {
+	now := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	calls := 0
+	h := newDeepHealth()
+	h.now = func() time.Time { return now }
+	h.add("viacep", func(ctx context.Context) error { calls++; return nil })
+	h.add("redis", func(ctx context.Context) error { return errors.New("connection refused") })
+
+	response := h.run(context.Background())
+	if response.Status != "degraded" {
+		t.Errorf("Expected status degraded, got %s", response.Status)
+	}
+	if response.Dependencies[0].Status != "ok" || response.Dependencies[1].Error != "connection refused" {
+		t.Errorf("Unexpected dependencies %+v", response.Dependencies)
+	}
+
+	h.run(context.Background())
+	if calls != 1 {
+		t.Errorf("Expected cached result within TTL, got %d calls", calls)
+	}
+	now = now.Add(deepHealthCacheTTL)
+	h.run(context.Background())
+	if calls != 2 {
+		t.Errorf("Expected checks to run again after TTL, got %d calls", calls)
+	}
+}
+
+func TestDependencyError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{"Timeout", context.DeadlineExceeded, "timeout"},
+		{"URL com a chave", &neturl.Error{Op: "Get", URL: "http://127.0.0.1:1/forecast.json?key=secret-key", Err: errors.New("connection refused")}, `Get "http://127.0.0.1:1/forecast.json": connection refused`},
+		{"Erro de status", errors.New("weather API error: 401"), "weather API error: 401"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dependencyError(tt.err); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestDeepHealth_BypassesBreakers(t *testing.T) {
+	env := newE2EEnv(t, func(cfg *Config) {
+		cfg.BreakerThreshold = 1
+		cfg.BreakerCooldown = time.Minute
+		cfg.WeatherAPIRetry = RetryPolicy{MaxAttempts: 1}
+	})
+	env.weatherAPI.set(func(f *fakeWeatherAPI) { f.status = http.StatusBadGateway })
+	env.get(t, "/health/deep", nil)
+	for _, breaker := range env.app.breakers {
+		if state := breaker.Snapshot(); state.State != breakerClosed {
+			t.Errorf("Expected the health check to leave breaker %s closed, got %s", state.Name, state.State)
+		}
+	}
+}
+
+func TestDeepHealth_Endpoint(t *testing.T) {
+	redisServer := miniredis.RunT(t)
+	tests := []struct {
+		name      string
+		configure func(env *e2eEnv)
+		expected  int
+		failing   string
+	}{
+		{"Todas as dependências saudáveis", func(env *e2eEnv) {}, http.StatusOK, ""},
+		{"Chave da WeatherAPI inválida", func(env *e2eEnv) {
+			env.weatherAPI.mu.Lock()
+			env.weatherAPI.apiKey = "other-key"
+			env.weatherAPI.mu.Unlock()
+		}, http.StatusServiceUnavailable, "weatherapi"},
+		{"ViaCEP fora do ar", func(env *e2eEnv) {
+			env.viaCEP.set(func(f *fakeViaCEP) { f.status = http.StatusBadGateway })
+		}, http.StatusServiceUnavailable, "viacep"},
+		{"Redis fora do ar", func(env *e2eEnv) { redisServer.Close() }, http.StatusServiceUnavailable, "redis"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newE2EEnv(t, func(cfg *Config) {
+				cfg.RedisAddr = redisServer.Addr()
+				cfg.ViaCEPRetry = RetryPolicy{MaxAttempts: 1}
+				cfg.WeatherAPIRetry = RetryPolicy{MaxAttempts: 1}
+			})
+			tt.configure(env)
+			resp, body := env.get(t, "/health/deep", nil)
+			if resp.StatusCode != tt.expected {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expected, resp.StatusCode, body)
+			}
+			var health DeepHealthResponse
+			if err := json.Unmarshal(body, &health); err != nil {
+				t.Fatalf("Error parsing response: %v", err)
+			}
+			if len(health.Dependencies) != 3 {
+				t.Fatalf("Expected 3 dependencies, got %+v", health.Dependencies)
+			}
+			for _, dep := range health.Dependencies {
+				if failed := dep.Status != "ok"; failed != (dep.Name == tt.failing) {
+					t.Errorf("Unexpected status for %s: %+v", dep.Name, dep)
+				}
+			}
+		})
+	}
+}
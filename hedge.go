@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// hedge starts primary and, if it is still running after delay, starts
+// secondary as well. The first result accepted by settled wins and the other
+// call is cancelled. When neither is accepted, primary's result is returned.
+// The bool result reports whether secondary was started.
+func hedge[T any](ctx context.Context, name string, delay time.Duration, settled func(error) bool,
+	primary, secondary func(context.Context) (T, error)) (T, bool, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		value   T
+		err     error
+		primary bool
+	}
+	results := make(chan result, 2)
+	run := func(fn func(context.Context) (T, error), isPrimary bool) {
+		go func() {
+			value, err := fn(ctx)
+			results <- result{value: value, err: err, primary: isPrimary}
+		}()
+	}
+
+	run(primary, true)
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	hedged, pending := false, 1
+	var primaryResult result
+	for {
+		select {
+		case <-timer.C:
+			slog.InfoContext(ctx, "Upstream slow, sending hedged request", "upstream", name, "after", delay)
+			hedged = true
+			pending++
+			run(secondary, false)
+		case r := <-results:
+			pending--
+			if settled(r.err) || !hedged {
+				return r.value, hedged, r.err
+			}
+			if r.primary {
+				primaryResult = r
+			}
+			if pending == 0 {
+				return primaryResult.value, hedged, primaryResult.err
+			}
+		}
+	}
+}
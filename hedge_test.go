@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHedge(t *testing.T) {
+	errPrimary := errors.New("primary failed")
+	errSecondary := errors.New("secondary failed")
+	settled := func(err error) bool { return err == nil }
+	waitForCancel := func(ctx context.Context) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}
+
+	tests := []struct {
+		name        string
+		primary     func(context.Context) (string, error)
+		secondary   func(context.Context) (string, error)
+		expected    string
+		expectedErr error
+		hedged      bool
+	}{
+		{"Primário rápido não dispara hedge", func(context.Context) (string, error) { return "primary", nil }, waitForCancel, "primary", nil, false},
+		{"Primário lento perde para o secundário", waitForCancel, func(context.Context) (string, error) { return "secondary", nil }, "secondary", nil, true},
+		{"Falha rápida do primário não dispara hedge", func(context.Context) (string, error) { return "", errPrimary }, waitForCancel, "", errPrimary, false},
+		{"Secundário falha e o primário ainda responde", func(context.Context) (string, error) {
+			time.Sleep(50 * time.Millisecond)
+			return "primary", nil
+		}, func(context.Context) (string, error) { return "", errSecondary }, "primary", nil, true},
+		{"Ambos falham retorna o erro do primário", func(context.Context) (string, error) {
+			time.Sleep(50 * time.Millisecond)
+			return "", errPrimary
+		}, func(context.Context) (string, error) { return "", errSecondary }, "", errPrimary, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, hedged, err := hedge(context.Background(), "test", 10*time.Millisecond, settled, tt.primary, tt.secondary)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("Expected error %v, got %v", tt.expectedErr, err)
+			}
+			if value != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, value)
+			}
+			if hedged != tt.hedged {
+				t.Errorf("Expected hedged=%v, got %v", tt.hedged, hedged)
+			}
+		})
+	}
+}
+
+// slowViaCEPClient holds ViaCEP requests until they are cancelled and
+// answers everything else from the mock.
+type slowViaCEPClient struct {
+	*MockHTTPClient
+	canceled int32
+}
+
+func (c *slowViaCEPClient) Do(req *http.Request) (*http.Response, error) {
+	if req.URL.Host == "viacep.com.br" {
+		<-req.Context().Done()
+		atomic.AddInt32(&c.canceled, 1)
+		return nil, req.Context().Err()
+	}
+	return c.MockHTTPClient.Do(req)
+}
+
+func TestCEPService_HedgesSlowViaCEP(t *testing.T) {
+	client := &slowViaCEPClient{MockHTTPClient: NewMockHTTPClient()}
+	client.AddResponse("https://brasilapi.local/api/cep/v1/01310100", 200, `{"cep": "01310100", "state": "SP", "city": "São Paulo"}`)
+	service := NewCEPService(client)
+	service.fallbacks = []CEPResolver{newBrasilAPIResolver(client, "https://brasilapi.local")}
+	service.hedgeAfter = 20 * time.Millisecond
+
+	result, err := service.GetCEPInfo(context.Background(), "01310100")
+	if err != nil {
+		t.Fatalf("Expected the hedged provider to answer, got %v", err)
+	}
+	if result.State != "SP" {
+		t.Errorf("Expected state 'SP', got '%s'", result.State)
+	}
+	if !waitFor(t, func() bool { return atomic.LoadInt32(&client.canceled) == 1 }) {
+		t.Error("Expected the slow ViaCEP request to be cancelled")
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"time"
+)
+
+// newHTTPClient builds the client shared by every upstream call. Timeout
+// bounds a whole request including reading the body; DialTimeout bounds
+// only connection setup, so an unreachable mirror fails fast.
+func newHTTPClient(cfg Config) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{
+		Timeout:   cfg.DialTimeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	transport.TLSHandshakeTimeout = cfg.DialTimeout
+	return &http.Client{Timeout: cfg.HTTPClientTimeout, Transport: transport}
+}
+
+// redactURLError strips the query string, which carries the WeatherAPI key,
+// from the URL a transport error quotes, so the error can leave the process
+// in traces, error reports or responses.
+func redactURLError(err error) error {
+	var urlErr *neturl.Error
+	if !errors.As(err, &urlErr) {
+		return err
+	}
+	redacted, _, _ := strings.Cut(urlErr.URL, "?")
+	if err == error(urlErr) {
+		return &neturl.Error{Op: urlErr.Op, URL: redacted, Err: urlErr.Err}
+	}
+	return errors.New(strings.ReplaceAll(err.Error(), urlErr.URL, redacted))
+}
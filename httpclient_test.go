@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	neturl "net/url"
+	"testing"
+	"time"
+)
+
+func TestNewHTTPClient(t *testing.T) {
+	client := newHTTPClient(Config{HTTPClientTimeout: 100 * time.Millisecond, DialTimeout: 50 * time.Millisecond})
+
+	t.Run("Upstream travado respeita o timeout total", func(t *testing.T) {
+		release := make(chan struct{})
+		hung := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+		}))
+		defer hung.Close()
+		defer close(release)
+
+		service := NewCEPService(client, hung.URL)
+		start := time.Now()
+		_, err := service.GetCEPInfo(context.Background(), "01310100")
+		var netErr net.Error
+		if !errors.As(err, &netErr) || !netErr.Timeout() {
+			t.Fatalf("Expected a timeout error, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed >= time.Second {
+			t.Errorf("Expected the lookup to give up after the client timeout, took %s", elapsed)
+		}
+	})
+
+	t.Run("Timeout de conexão aplicado ao transporte", func(t *testing.T) {
+		transport := client.Transport.(*http.Transport)
+		if transport.TLSHandshakeTimeout != 50*time.Millisecond {
+			t.Errorf("Expected TLS handshake timeout 50ms, got %s", transport.TLSHandshakeTimeout)
+		}
+		if transport == http.DefaultTransport {
+			t.Error("Expected a dedicated transport, not http.DefaultTransport")
+		}
+	})
+}
+
+func TestRedactURLError(t *testing.T) {
+	urlErr := &neturl.Error{Op: "Get", URL: "http://127.0.0.1:1/forecast.json?key=secret&q=Sao+Paulo", Err: errors.New("connection refused")}
+
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{"Erro de URL", urlErr, `Get "http://127.0.0.1:1/forecast.json": connection refused`},
+		{"Erro de URL embrulhado", fmt.Errorf("lookup failed: %w", urlErr), `lookup failed: Get "http://127.0.0.1:1/forecast.json": connection refused`},
+		{"Outro erro", errors.New("weather API error: 502"), "weather API error: 502"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redactURLError(tt.err).Error(); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+	if !errors.Is(redactURLError(&neturl.Error{Op: "Get", URL: "http://x/?key=secret", Err: context.DeadlineExceeded}), context.DeadlineExceeded) {
+		t.Error("Expected the redacted error to keep its cause")
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const invalidationChannel = "projetodeploy:cache-invalidation"
+
+const (
+	invalidateCEP     = "cep"
+	invalidateWeather = "weather"
+	invalidateAll     = "all"
+)
+
+// invalidation tells other replicas to drop an entry from their in-process
+// caches. Shared tiers (Redis) are purged once by the replica that received
+// the admin request.
+type invalidation struct {
+	Source string `json:"source"`
+	Scope  string `json:"scope"`
+	Key    string `json:"key,omitempty"`
+}
+
+type invalidationBus interface {
+	Publish(ctx context.Context, msg invalidation) error
+	// Subscribe delivers messages from other replicas until ctx is done. It
+	// returns once the subscription is active.
+	Subscribe(ctx context.Context, handle func(invalidation)) error
+}
+
+type RedisInvalidationBus struct {
+	client     *redis.Client
+	instanceID string
+}
+
+func NewRedisInvalidationBus(client *redis.Client) *RedisInvalidationBus {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return &RedisInvalidationBus{client: client, instanceID: hex.EncodeToString(buf)}
+}
+
+func (b *RedisInvalidationBus) Publish(ctx context.Context, msg invalidation) error {
+	msg.Source = b.instanceID
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return b.client.Publish(ctx, invalidationChannel, data).Err()
+}
+
+func (b *RedisInvalidationBus) Subscribe(ctx context.Context, handle func(invalidation)) error {
+	pubsub := b.client.Subscribe(ctx, invalidationChannel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return err
+	}
+	go func() {
+		defer pubsub.Close()
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case raw, ok := <-messages:
+				if !ok {
+					return
+				}
+				var msg invalidation
+				if err := json.Unmarshal([]byte(raw.Payload), &msg); err != nil {
+					slog.Warn("Ignoring malformed cache invalidation", "error", err)
+					continue
+				}
+				if msg.Source != b.instanceID {
+					handle(msg)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+func (app *App) publishInvalidation(ctx context.Context, msg invalidation) {
+	if app.invalidations == nil {
+		return
+	}
+	if err := app.invalidations.Publish(ctx, msg); err != nil {
+		slog.Error("Error publishing cache invalidation", "error", err)
+	}
+}
+
+// applyInvalidation drops entries from this replica's in-process caches.
+func (app *App) applyInvalidation(msg invalidation) {
+	l1, _ := app.cepCache.(*TieredCEPCache)
+	weather, forecasts := app.weatherCache, app.forecastCache
+	switch msg.Scope {
+	case invalidateCEP:
+		if l1 != nil {
+			l1.l1.Delete(msg.Key)
+		}
+	case invalidateWeather:
+		if weather != nil {
+			weather.Delete(msg.Key)
+		}
+		if forecasts != nil {
+			forecasts.Delete(forecastCacheKey(msg.Key, forecastDays))
+		}
+	case invalidateAll:
+		if l1 != nil {
+			l1.l1.Purge()
+		}
+		if weather != nil {
+			weather.Purge()
+		}
+		if forecasts != nil {
+			forecasts.Purge()
+		}
+	}
+}
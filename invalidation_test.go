@@ -0,0 +1,80 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func waitFor(t *testing.T, condition func() bool) bool {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if condition() {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
+
+func TestE2E_InvalidationFanOut(t *testing.T) {
+	redisServer := miniredis.RunT(t)
+	configure := func(cfg *Config) {
+		cfg.AdminToken = "admin-secret"
+		cfg.RedisAddr = redisServer.Addr()
+		cfg.CEPCacheTTL = time.Hour
+		cfg.CEPCacheL1Size = 10
+		cfg.CEPCacheL1TTL = time.Hour
+	}
+	replicas := []*e2eEnv{newE2EEnv(t, configure), newE2EEnv(t, configure), newE2EEnv(t, configure)}
+	warm := func() {
+		for _, env := range replicas {
+			env.get(t, "/weather/01310100", nil)
+		}
+	}
+	l1 := func(env *e2eEnv) *lruCache[*Address] {
+		return env.app.cepCache.(*TieredCEPCache).l1
+	}
+
+	t.Run("Remoção de CEP chega a todas as réplicas", func(t *testing.T) {
+		warm()
+		if status := replicas[0].do(t, "DELETE", "/admin/cache/cep/01310100", "admin-secret"); status != http.StatusNoContent {
+			t.Fatalf("Expected status 204, got %d", status)
+		}
+		for i, env := range replicas {
+			if !waitFor(t, func() bool { return l1(env).Len() == 0 }) {
+				t.Errorf("Expected replica %d to drop the CEP from L1", i)
+			}
+		}
+	})
+
+	t.Run("Remoção de cidade chega a todas as réplicas", func(t *testing.T) {
+		warm()
+		if status := replicas[1].do(t, "DELETE", "/admin/cache/weather?ibge=3550308", "admin-secret"); status != http.StatusNoContent {
+			t.Fatalf("Expected status 204, got %d", status)
+		}
+		for i, env := range replicas {
+			if !waitFor(t, func() bool { return env.app.weatherCache.Len() == 0 }) {
+				t.Errorf("Expected replica %d to drop the weather entry", i)
+			}
+			if l1(env).Len() != 1 {
+				t.Errorf("Expected replica %d to keep its CEP entries", i)
+			}
+		}
+	})
+
+	t.Run("Limpeza total chega a todas as réplicas", func(t *testing.T) {
+		warm()
+		if status := replicas[2].do(t, "DELETE", "/admin/cache", "admin-secret"); status != http.StatusNoContent {
+			t.Fatalf("Expected status 204, got %d", status)
+		}
+		for i, env := range replicas {
+			if !waitFor(t, func() bool { return l1(env).Len() == 0 && env.app.weatherCache.Len() == 0 }) {
+				t.Errorf("Expected replica %d to purge its in-memory caches", i)
+			}
+		}
+	})
+}
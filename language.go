@@ -0,0 +1,42 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+const (
+	langPortuguese = "pt-BR"
+	langEnglish    = "en"
+	langSpanish    = "es"
+	defaultLang    = langPortuguese
+)
+
+func matchLanguage(tag string) (string, bool) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	switch {
+	case strings.HasPrefix(tag, "pt"):
+		return langPortuguese, true
+	case strings.HasPrefix(tag, "en"):
+		return langEnglish, true
+	case strings.HasPrefix(tag, "es"):
+		return langSpanish, true
+	}
+	return "", false
+}
+
+// requestLanguage picks the response language from ?lang= or, failing that,
+// the first supported entry of Accept-Language. Quality values are ignored:
+// clients list their preferred language first in practice.
+func requestLanguage(r *http.Request) string {
+	if lang, ok := matchLanguage(r.URL.Query().Get("lang")); ok {
+		return lang
+	}
+	for _, part := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		tag := strings.SplitN(part, ";", 2)[0]
+		if lang, ok := matchLanguage(tag); ok {
+			return lang
+		}
+	}
+	return defaultLang
+}
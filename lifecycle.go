@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// Component is a subsystem with its own background work or resources.
+// Start must not block; Stop releases whatever Start acquired.
+type Component struct {
+	Name      string
+	DependsOn []string
+	Start     func(ctx context.Context) error
+	Stop      func(ctx context.Context) error
+}
+
+// Lifecycle starts components after their dependencies and stops them
+// before their dependencies. Components that do not depend on each other
+// are stopped in parallel, at most stopParallelism at a time.
+type Lifecycle struct {
+	mu              sync.Mutex
+	components      []Component
+	started         []Component
+	stopParallelism int
+}
+
+func NewLifecycle(stopParallelism int) *Lifecycle {
+	if stopParallelism < 1 {
+		stopParallelism = 1
+	}
+	return &Lifecycle{stopParallelism: stopParallelism}
+}
+
+func (l *Lifecycle) Register(c Component) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.components = append(l.components, c)
+}
+
+// startOrder sorts components so each one comes after its dependencies,
+// rejecting unknown dependencies and cycles.
+func (l *Lifecycle) startOrder() ([]Component, error) {
+	byName := make(map[string]Component, len(l.components))
+	for _, c := range l.components {
+		if _, dup := byName[c.Name]; dup {
+			return nil, fmt.Errorf("component %s registered twice", c.Name)
+		}
+		byName[c.Name] = c
+	}
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int)
+	var order []Component
+	var visit func(c Component) error
+	visit = func(c Component) error {
+		switch state[c.Name] {
+		case visiting:
+			return fmt.Errorf("dependency cycle at component %s", c.Name)
+		case visited:
+			return nil
+		}
+		state[c.Name] = visiting
+		for _, dep := range c.DependsOn {
+			depComponent, ok := byName[dep]
+			if !ok {
+				return fmt.Errorf("component %s depends on unknown component %s", c.Name, dep)
+			}
+			if err := visit(depComponent); err != nil {
+				return err
+			}
+		}
+		state[c.Name] = visited
+		order = append(order, c)
+		return nil
+	}
+	for _, c := range l.components {
+		if err := visit(c); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// Start starts every component in dependency order. If one fails, the ones
+// already started are stopped before the error is returned.
+func (l *Lifecycle) Start(ctx context.Context) error {
+	l.mu.Lock()
+	order, err := l.startOrder()
+	l.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	for _, c := range order {
+		if c.Start != nil {
+			if err := c.Start(ctx); err != nil {
+				l.Stop(ctx)
+				return fmt.Errorf("starting %s: %w", c.Name, err)
+			}
+		}
+		l.mu.Lock()
+		l.started = append(l.started, c)
+		l.mu.Unlock()
+		slog.Debug("Component started", "component", c.Name)
+	}
+	return nil
+}
+
+// Stop stops started components in waves: a component is stopped only once
+// everything that depends on it has stopped.
+func (l *Lifecycle) Stop(ctx context.Context) error {
+	l.mu.Lock()
+	started := l.started
+	l.started = nil
+	l.mu.Unlock()
+
+	waves := stopWaves(started)
+	var errs []error
+	var errsMu sync.Mutex
+	for _, wave := range waves {
+		sem := make(chan struct{}, l.stopParallelism)
+		var wg sync.WaitGroup
+		for _, c := range wave {
+			if c.Stop == nil {
+				continue
+			}
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(c Component) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				if err := c.Stop(ctx); err != nil {
+					errsMu.Lock()
+					errs = append(errs, fmt.Errorf("stopping %s: %w", c.Name, err))
+					errsMu.Unlock()
+					return
+				}
+				slog.Debug("Component stopped", "component", c.Name)
+			}(c)
+		}
+		wg.Wait()
+	}
+	return errors.Join(errs...)
+}
+
+// stopWaves groups components by how far they are from having no
+// dependents: wave 0 has nothing depending on it, wave 1 only has
+// dependents in wave 0, and so on.
+func stopWaves(components []Component) [][]Component {
+	dependents := make(map[string][]string)
+	for _, c := range components {
+		for _, dep := range c.DependsOn {
+			dependents[dep] = append(dependents[dep], c.Name)
+		}
+	}
+	level := make(map[string]int)
+	var depth func(name string) int
+	depth = func(name string) int {
+		if d, ok := level[name]; ok {
+			return d
+		}
+		d := 0
+		for _, dependent := range dependents[name] {
+			d = max(d, depth(dependent)+1)
+		}
+		level[name] = d
+		return d
+	}
+	var waves [][]Component
+	for _, c := range components {
+		d := depth(c.Name)
+		for len(waves) <= d {
+			waves = append(waves, nil)
+		}
+		waves[d] = append(waves[d], c)
+	}
+	return waves
+}
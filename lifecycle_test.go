@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type lifecycleRecorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *lifecycleRecorder) record(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *lifecycleRecorder) index(event string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, e := range r.events {
+		if e == event {
+			return i
+		}
+	}
+	return -1
+}
+
+func (r *lifecycleRecorder) component(name string, deps ...string) Component {
+	return Component{
+		Name:      name,
+		DependsOn: deps,
+		Start:     func(ctx context.Context) error { r.record("start " + name); return nil },
+		Stop:      func(ctx context.Context) error { r.record("stop " + name); return nil },
+	}
+}
+
+func TestLifecycle_Order(t *testing.T) {
+	recorder := &lifecycleRecorder{}
+	lifecycle := NewLifecycle(2)
+	lifecycle.Register(recorder.component("server", "cache", "health"))
+	lifecycle.Register(recorder.component("cache", "redis"))
+	lifecycle.Register(recorder.component("redis"))
+	lifecycle.Register(recorder.component("health"))
+
+	if err := lifecycle.Start(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := lifecycle.Stop(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	before := []struct{ first, then string }{
+		{"start redis", "start cache"},
+		{"start cache", "start server"},
+		{"start health", "start server"},
+		{"stop server", "stop cache"},
+		{"stop server", "stop health"},
+		{"stop cache", "stop redis"},
+	}
+	for _, b := range before {
+		if recorder.index(b.first) > recorder.index(b.then) || recorder.index(b.first) < 0 {
+			t.Errorf("Expected %q before %q, got %v", b.first, b.then, recorder.events)
+		}
+	}
+}
+
+func TestLifecycle_InvalidDependencies(t *testing.T) {
+	recorder := &lifecycleRecorder{}
+	tests := []struct {
+		name       string
+		components []Component
+		expected   string
+	}{
+		{"Dependência desconhecida", []Component{recorder.component("cache", "redis")}, "unknown component redis"},
+		{"Dependência circular", []Component{recorder.component("a", "b"), recorder.component("b", "a")}, "dependency cycle"},
+		{"Componente duplicado", []Component{recorder.component("a"), recorder.component("a")}, "registered twice"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lifecycle := NewLifecycle(1)
+			for _, c := range tt.components {
+				lifecycle.Register(c)
+			}
+			if err := lifecycle.Start(context.Background()); err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestLifecycle_StartFailureStopsStartedComponents(t *testing.T) {
+	recorder := &lifecycleRecorder{}
+	lifecycle := NewLifecycle(1)
+	lifecycle.Register(recorder.component("redis"))
+	lifecycle.Register(Component{
+		Name:      "server",
+		DependsOn: []string{"redis"},
+		Start:     func(ctx context.Context) error { return errors.New("address in use") },
+	})
+
+	if err := lifecycle.Start(context.Background()); err == nil || !strings.Contains(err.Error(), "starting server") {
+		t.Fatalf("Expected start error, got %v", err)
+	}
+	if recorder.index("stop redis") < 0 {
+		t.Errorf("Expected redis to be stopped after the failed start, got %v", recorder.events)
+	}
+}
+
+func TestLifecycle_BoundedParallelStop(t *testing.T) {
+	lifecycle := NewLifecycle(2)
+	var mu sync.Mutex
+	running, peak := 0, 0
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		lifecycle.Register(Component{Name: name, Stop: func(ctx context.Context) error {
+			mu.Lock()
+			running++
+			peak = max(peak, running)
+			mu.Unlock()
+			time.Sleep(20 * time.Millisecond)
+			mu.Lock()
+			running--
+			mu.Unlock()
+			return nil
+		}})
+	}
+	lifecycle.Start(context.Background())
+
+	start := time.Now()
+	if err := lifecycle.Stop(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if peak != 2 {
+		t.Errorf("Expected at most 2 concurrent stops, got %d", peak)
+	}
+	if elapsed := time.Since(start); elapsed >= 100*time.Millisecond {
+		t.Errorf("Expected independent components to stop in parallel, took %s", elapsed)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// newLogger builds the process logger. "json" is meant for Cloud Logging
+// and other collectors; "console" prints key=value lines for local runs.
+func newLogger(out io.Writer, level, format string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid LOG_LEVEL %q", level)
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+	switch strings.ToLower(format) {
+	case "json":
+		return slog.New(contextHandler{slog.NewJSONHandler(out, opts)}), nil
+	case "console":
+		return slog.New(contextHandler{slog.NewTextHandler(out, opts)}), nil
+	}
+	return nil, fmt.Errorf("invalid LOG_FORMAT %q", format)
+}
+
+// contextHandler adds the request ID to lines logged with a request context.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := requestIDFromContext(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// fatal logs at error level and exits, standing in for log.Fatal.
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// upstreamCalls collects the status of each upstream call made while
+// serving one request, so the request's log line can report them.
+type upstreamCalls struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+type upstreamCallsKey struct{}
+
+func withUpstreamCalls(ctx context.Context) (context.Context, *upstreamCalls) {
+	calls := &upstreamCalls{}
+	return context.WithValue(ctx, upstreamCallsKey{}, calls), calls
+}
+
+func recordUpstreamCall(ctx context.Context, upstream, outcome string) {
+	if calls := upstreamCallsFrom(ctx); calls != nil {
+		calls.mu.Lock()
+		calls.calls = append(calls.calls, upstream+"="+outcome)
+		calls.mu.Unlock()
+	}
+}
+
+// upstreamCallsFrom returns the collector withUpstreamCalls attached to ctx,
+// or nil for requests that do not report their upstream calls.
+func upstreamCallsFrom(ctx context.Context) *upstreamCalls {
+	calls, _ := ctx.Value(upstreamCallsKey{}).(*upstreamCalls)
+	return calls
+}
+
+func (c *upstreamCalls) list() []string {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.calls...)
+}
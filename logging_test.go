@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestNewLogger(t *testing.T) {
+	t.Run("JSON inclui nível, mensagem e campos", func(t *testing.T) {
+		var out bytes.Buffer
+		logger, err := newLogger(&out, "info", "json")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		logger.Info("Weather lookup served", "cep", "01310100", "latency_ms", 12)
+
+		var entry map[string]any
+		if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+			t.Fatalf("Expected a JSON line, got %q: %v", out.String(), err)
+		}
+		if entry["level"] != "INFO" || entry["msg"] != "Weather lookup served" || entry["cep"] != "01310100" {
+			t.Errorf("Unexpected entry %v", entry)
+		}
+	})
+
+	t.Run("Nível filtra mensagens abaixo dele", func(t *testing.T) {
+		var out bytes.Buffer
+		logger, err := newLogger(&out, "warn", "console")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		logger.Info("dropped")
+		logger.Warn("kept", "cep", "01310100")
+		if strings.Contains(out.String(), "dropped") {
+			t.Errorf("Expected info line to be filtered, got %q", out.String())
+		}
+		if !strings.Contains(out.String(), "cep=01310100") {
+			t.Errorf("Expected key=value output, got %q", out.String())
+		}
+	})
+
+	tests := []struct {
+		name   string
+		level  string
+		format string
+	}{
+		{"Nível inválido", "verbose", "json"},
+		{"Formato inválido", "info", "xml"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newLogger(&bytes.Buffer{}, tt.level, tt.format); err == nil {
+				t.Errorf("Expected error for level %q format %q", tt.level, tt.format)
+			}
+		})
+	}
+}
+
+func TestUpstreamCalls(t *testing.T) {
+	mockClient := NewMockHTTPClient()
+	mockClient.AddResponse("https://viacep.com.br/ws/01310100/json/", 200, `{"localidade": "São Paulo", "uf": "SP"}`)
+	client := metricsHTTPClient{next: mockClient, metrics: newMetrics()}
+
+	ctx, calls := withUpstreamCalls(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://viacep.com.br/ws/01310100/json/", nil)
+	if _, err := client.Do(req); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if got := calls.list(); len(got) != 1 || got[0] != "viacep.com.br=200" {
+		t.Errorf("Expected [viacep.com.br=200], got %v", got)
+	}
+}
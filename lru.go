@@ -0,0 +1,182 @@
+package main
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+type lruEntry[V any] struct {
+	key       string
+	value     V
+	expiresAt time.Time
+}
+
+// lruCache is a bounded, TTL-aware LRU cache safe for concurrent use.
+// Entries past their TTL are kept for staleTTL longer so callers may serve
+// them while refreshing in the background.
+type lruCache[V any] struct {
+	mu         sync.Mutex
+	maxEntries int
+	ttl        time.Duration
+	staleTTL   time.Duration
+	jitter     float64
+	items      map[string]*list.Element
+	order      *list.List
+	now        func() time.Time
+	sizeOf     func(V) int64
+	// keepExpired leaves entries past the stale window in place, still
+	// bounded by maxEntries, so Last can serve them when the upstream is
+	// unavailable for good.
+	keepExpired bool
+	hits        uint64
+	misses      uint64
+}
+
+func newLRUCache[V any](maxEntries int, ttl time.Duration) *lruCache[V] {
+	return &lruCache[V]{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		items:      make(map[string]*list.Element),
+		order:      list.New(),
+		now:        time.Now,
+	}
+}
+
+func (c *lruCache[V]) Get(key string) (V, bool) {
+	value, fresh, ok := c.GetStale(key)
+	if !ok || !fresh {
+		var zero V
+		return zero, false
+	}
+	return value, true
+}
+
+// GetStale also returns entries that expired less than staleTTL ago,
+// reporting whether the value is still fresh.
+func (c *lruCache[V]) GetStale(key string) (value V, fresh bool, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, found := c.items[key]
+	if !found {
+		c.misses++
+		return value, false, false
+	}
+	entry := elem.Value.(*lruEntry[V])
+	now := c.now()
+	if !now.Before(entry.expiresAt.Add(c.staleTTL)) {
+		if !c.keepExpired {
+			c.removeElement(elem)
+		}
+		c.misses++
+		return value, false, false
+	}
+	c.hits++
+	c.order.MoveToFront(elem)
+	return entry.value, now.Before(entry.expiresAt), true
+}
+
+// Last returns the entry for key however old it is; only useful with
+// keepExpired.
+func (c *lruCache[V]) Last(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.items[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	return elem.Value.(*lruEntry[V]).value, true
+}
+
+func (c *lruCache[V]) Set(key string, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	expiresAt := c.now().Add(jitteredTTL(c.ttl, c.jitter))
+	if elem, ok := c.items[key]; ok {
+		entry := elem.Value.(*lruEntry[V])
+		entry.value = value
+		entry.expiresAt = expiresAt
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.items[key] = c.order.PushFront(&lruEntry[V]{key: key, value: value, expiresAt: expiresAt})
+	for c.order.Len() > c.maxEntries {
+		c.removeElement(c.order.Back())
+	}
+}
+
+func (c *lruCache[V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *lruCache[V]) Delete(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.items[key]
+	if ok {
+		c.removeElement(elem)
+	}
+	return ok
+}
+
+func (c *lruCache[V]) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items = make(map[string]*list.Element)
+	c.order.Init()
+}
+
+// Stats reports usage counters and, when sizeOf is set, an estimate of the
+// memory held by the cached values.
+func (c *lruCache[V]) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var memoryBytes int64
+	if c.sizeOf != nil {
+		for elem := c.order.Front(); elem != nil; elem = elem.Next() {
+			memoryBytes += c.sizeOf(elem.Value.(*lruEntry[V]).value)
+		}
+	}
+	return newCacheStats("memory", int64(c.order.Len()), c.hits, c.misses, memoryBytes)
+}
+
+type lruSnapshotEntry[V any] struct {
+	Key       string    `json:"key"`
+	Value     V         `json:"value"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Snapshot lists the entries still within their stale window, most recently
+// used first.
+func (c *lruCache[V]) Snapshot() []lruSnapshotEntry[V] {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	entries := make([]lruSnapshotEntry[V], 0, c.order.Len())
+	for elem := c.order.Front(); elem != nil; elem = elem.Next() {
+		entry := elem.Value.(*lruEntry[V])
+		if now.Before(entry.expiresAt.Add(c.staleTTL)) {
+			entries = append(entries, lruSnapshotEntry[V]{Key: entry.key, Value: entry.value, ExpiresAt: entry.expiresAt})
+		}
+	}
+	return entries
+}
+
+// Restore inserts an entry keeping its original expiry, so imported data is
+// not treated as fresher than it is.
+func (c *lruCache[V]) Restore(key string, value V, expiresAt time.Time) {
+	c.Set(key, value)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.items[key]; ok {
+		elem.Value.(*lruEntry[V]).expiresAt = expiresAt
+	}
+}
+
+func (c *lruCache[V]) removeElement(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.items, elem.Value.(*lruEntry[V]).key)
+}
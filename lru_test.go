@@ -0,0 +1,175 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLRUCache(t *testing.T) {
+	t.Run("Remove a entrada menos usada ao exceder o limite", func(t *testing.T) {
+		cache := newLRUCache[int](2, time.Minute)
+		cache.Set("a", 1)
+		cache.Set("b", 2)
+		cache.Get("a")
+		cache.Set("c", 3)
+
+		if _, ok := cache.Get("b"); ok {
+			t.Error("Expected 'b' to be evicted")
+		}
+		if value, ok := cache.Get("a"); !ok || value != 1 {
+			t.Errorf("Expected 'a' = 1, got %d (found=%v)", value, ok)
+		}
+		if cache.Len() != 2 {
+			t.Errorf("Expected 2 entries, got %d", cache.Len())
+		}
+	})
+
+	t.Run("Entradas expiram após o TTL", func(t *testing.T) {
+		now := time.Date(2024, 5, 17, 13, 0, 0, 0, time.UTC)
+		cache := newLRUCache[int](10, time.Minute)
+		cache.now = func() time.Time { return now }
+		cache.Set("a", 1)
+
+		now = now.Add(59 * time.Second)
+		if _, ok := cache.Get("a"); !ok {
+			t.Error("Expected 'a' before TTL")
+		}
+
+		now = now.Add(time.Second)
+		if _, ok := cache.Get("a"); ok {
+			t.Error("Expected 'a' to expire after TTL")
+		}
+		if cache.Len() != 0 {
+			t.Errorf("Expected expired entry to be removed, got %d entries", cache.Len())
+		}
+	})
+
+	t.Run("Entrada expirada mantida para Last", func(t *testing.T) {
+		now := time.Date(2024, 5, 17, 13, 0, 0, 0, time.UTC)
+		cache := newLRUCache[int](10, time.Minute)
+		cache.now = func() time.Time { return now }
+		cache.keepExpired = true
+		cache.Set("a", 1)
+
+		now = now.Add(time.Hour)
+		if _, ok := cache.Get("a"); ok {
+			t.Error("Expected 'a' to expire after TTL")
+		}
+		if value, ok := cache.Last("a"); !ok || value != 1 {
+			t.Errorf("Expected Last to return the expired value 1, got %d (%v)", value, ok)
+		}
+	})
+
+	t.Run("Atualizar renova valor e TTL", func(t *testing.T) {
+		now := time.Date(2024, 5, 17, 13, 0, 0, 0, time.UTC)
+		cache := newLRUCache[int](10, time.Minute)
+		cache.now = func() time.Time { return now }
+		cache.Set("a", 1)
+		now = now.Add(50 * time.Second)
+		cache.Set("a", 2)
+		now = now.Add(50 * time.Second)
+
+		if value, ok := cache.Get("a"); !ok || value != 2 {
+			t.Errorf("Expected 'a' = 2, got %d (found=%v)", value, ok)
+		}
+	})
+}
+
+func TestHandleWeatherByCEP_CacheHeader(t *testing.T) {
+	mockClient := NewMockHTTPClient()
+	weatherService := NewWeatherService(mockClient, "test-api-key")
+	weatherService.cache = newLRUCache[*Observation](10, time.Minute)
+	app := NewApp(NewCEPService(mockClient), weatherService)
+	router := app.setupRoutes()
+	weatherURL := "https://api.weatherapi.com/v1/forecast.json?key=test-api-key&q=Sao+Paulo%2CSP%2CBrazil&days=1&aqi=no&alerts=no"
+	mockClient.AddResponse("https://viacep.com.br/ws/01310100/json/", 200, `{"localidade": "São Paulo", "uf": "SP"}`)
+	mockClient.AddResponse(weatherURL, 200, `{"current": {"temp_c": 25.0}}`)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/weather/01310100", nil))
+	if cacheHeader := rr.Header().Get("X-Cache"); cacheHeader != "MISS" {
+		t.Errorf("Expected X-Cache MISS on first lookup, got %s", cacheHeader)
+	}
+
+	mockClient.AddResponse("https://viacep.com.br/ws/01310100/json/", 200, `{"localidade": "São Paulo", "uf": "SP"}`)
+	mockClient.AddResponse(weatherURL, 500, "")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/weather/01310100", nil))
+	if rr.Code != 200 {
+		t.Fatalf("Expected cached weather to be served, got status %d", rr.Code)
+	}
+	if cacheHeader := rr.Header().Get("X-Cache"); cacheHeader != "HIT" {
+		t.Errorf("Expected X-Cache HIT on second lookup, got %s", cacheHeader)
+	}
+}
+
+func TestWeatherCacheKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		address  *Address
+		expected string
+	}{
+		{"Código IBGE", &Address{City: "São Paulo", State: "SP", IBGE: "3550308"}, "ibge:3550308"},
+		{"Sem código IBGE", &Address{City: "São Paulo", State: "sp"}, "city:sao paulo|SP"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := weatherCacheKey(tt.address); result != tt.expected {
+				t.Errorf("weatherCacheKey() = %s, expected %s", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHandleWeatherByCEP_CacheSharedByMunicipality(t *testing.T) {
+	mockClient := NewMockHTTPClient()
+	weatherService := NewWeatherService(mockClient, "test-api-key")
+	weatherService.cache = newLRUCache[*Observation](10, time.Minute)
+	app := NewApp(NewCEPService(mockClient), weatherService)
+	router := app.setupRoutes()
+	mockClient.AddResponse("https://viacep.com.br/ws/01310100/json/", 200, `{"localidade": "São Paulo", "uf": "SP", "ibge": "3550308"}`)
+	mockClient.AddResponse("https://viacep.com.br/ws/04538133/json/", 200, `{"localidade": "São Paulo", "uf": "SP", "ibge": "3550308"}`)
+	mockClient.AddResponse("https://api.weatherapi.com/v1/forecast.json?key=test-api-key&q=Sao+Paulo%2CSP%2CBrazil&days=1&aqi=no&alerts=no", 200, `{"current": {"temp_c": 25.0}}`)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/weather/01310100", nil))
+	if cacheHeader := rr.Header().Get("X-Cache"); cacheHeader != "MISS" {
+		t.Errorf("Expected X-Cache MISS for first CEP, got %s", cacheHeader)
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/weather/04538133", nil))
+	if cacheHeader := rr.Header().Get("X-Cache"); cacheHeader != "HIT" {
+		t.Errorf("Expected X-Cache HIT for another CEP in the same municipality, got %s", cacheHeader)
+	}
+	if weatherService.cache.Len() != 1 {
+		t.Errorf("Expected a single cache entry, got %d", weatherService.cache.Len())
+	}
+}
+
+func TestLRUCache_GetStale(t *testing.T) {
+	now := time.Date(2024, 5, 17, 13, 0, 0, 0, time.UTC)
+	cache := newLRUCache[int](10, time.Minute)
+	cache.staleTTL = 10 * time.Minute
+	cache.now = func() time.Time { return now }
+	cache.Set("a", 1)
+
+	if value, fresh, ok := cache.GetStale("a"); !ok || !fresh || value != 1 {
+		t.Errorf("Expected fresh 'a' = 1, got %d (fresh=%v, found=%v)", value, fresh, ok)
+	}
+
+	now = now.Add(5 * time.Minute)
+	if _, ok := cache.Get("a"); ok {
+		t.Error("Expected Get to ignore stale entries")
+	}
+	if value, fresh, ok := cache.GetStale("a"); !ok || fresh || value != 1 {
+		t.Errorf("Expected stale 'a' = 1, got %d (fresh=%v, found=%v)", value, fresh, ok)
+	}
+
+	now = now.Add(6 * time.Minute)
+	if _, _, ok := cache.GetStale("a"); ok {
+		t.Error("Expected entry to be dropped after the stale window")
+	}
+}
@@ -1,18 +1,30 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"math"
 	"net/http"
+	neturl "net/url"
+	"os"
+	"os/signal"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	"unicode"
 
 	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
-	"github.com/spf13/viper"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
 	"golang.org/x/text/transform"
 	"golang.org/x/text/unicode/norm"
 )
@@ -48,35 +60,85 @@ type WeatherAPIResponse struct {
 		TempC            float64 `json:"temp_c"`
 		TempF            float64 `json:"temp_f"`
 		IsDay            int     `json:"is_day"`
+		UV               float64 `json:"uv"`
 		Condition        struct {
 			Text string `json:"text"`
 			Icon string `json:"icon"`
 			Code int    `json:"code"`
 		} `json:"condition"`
 	} `json:"current"`
+	Forecast struct {
+		Forecastday []struct {
+			Hour []struct {
+				TimeEpoch    int64   `json:"time_epoch"`
+				TempC        float64 `json:"temp_c"`
+				PrecipMM     float64 `json:"precip_mm"`
+				WindKph      float64 `json:"wind_kph"`
+				ChanceOfRain int     `json:"chance_of_rain"`
+			} `json:"hour"`
+		} `json:"forecastday"`
+	} `json:"forecast"`
+	Alerts struct {
+		Alert []struct {
+			Headline string `json:"headline"`
+			Severity string `json:"severity"`
+			Event    string `json:"event"`
+		} `json:"alert"`
+	} `json:"alerts"`
 }
 
 type TemperatureResponse struct {
-	TempC float64 `json:"temp_C"`
-	TempF float64 `json:"temp_F"`
-	TempK float64 `json:"temp_K"`
+	TempC        float64        `json:"temp_C"`
+	TempF        float64        `json:"temp_F"`
+	TempK        float64        `json:"temp_K"`
+	Condition    *ConditionInfo `json:"condition,omitempty"`
+	UVAdvisory   *UVAdvisory    `json:"uv_advisory,omitempty"`
+	ChanceOfRain *int           `json:"chance_of_rain,omitempty"`
+	// CoordinatesIgnored flags lat/lon that fell outside the municipality:
+	// the response is the municipality's weather instead.
+	CoordinatesIgnored bool `json:"coordinates_ignored,omitempty"`
 }
 
 type ErrorResponse struct {
-	Message string `json:"message"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"`
 }
 
+var ErrCEPNotFound = errors.New("CEP not found")
+
 type CEPService struct {
 	httpClient HTTPClient
+	retry      RetryPolicy
+	breaker    *CircuitBreaker
+	endpoints  *EndpointPool
+	fallbacks  []CEPResolver
+	cache      CEPCache
+	flight     singleflight.Group
+	hedgeAfter time.Duration
 }
 
 type WeatherService struct {
 	httpClient HTTPClient
+	retry      RetryPolicy
+	breaker    *CircuitBreaker
 	apiKey     string
+	endpoints  *EndpointPool
+	quota      *weatherQuota
+	cache      *lruCache[*Observation]
+	forecasts  *lruCache[*Forecast]
+	flight     singleflight.Group
+	hedgeAfter time.Duration
+	// source replaces WeatherAPI as the upstream when set.
+	source WeatherSource
+	// quotaFallback answers, when set, while the WeatherAPI quota is
+	// exhausted and there is no cached observation to serve.
+	quotaFallback WeatherSource
+	// refreshing counts stale entries being refreshed in the background.
+	refreshing atomic.Int64
 }
 
 type HTTPClient interface {
-	Get(url string) (*http.Response, error)
+	Do(req *http.Request) (*http.Response, error)
 }
 
 func celsiusToFahrenheit(celsius float64) float64 {
@@ -100,14 +162,78 @@ func normalizeCEP(cep string) string {
 	return cep
 }
 
-func NewCEPService(client HTTPClient) *CEPService {
-	return &CEPService{httpClient: client}
+func NewCEPService(client HTTPClient, baseURLs ...string) *CEPService {
+	if len(baseURLs) == 0 {
+		baseURLs = []string{defaultViaCEPBaseURL}
+	}
+	return &CEPService{
+		httpClient: client,
+		endpoints:  NewEndpointPool(client, viaCEPProbePath, baseURLs...),
+	}
+}
+
+func (s *CEPService) GetCEPInfo(ctx context.Context, cep string) (*Address, error) {
+	if s.cache == nil {
+		return s.fetchCEPInfoShared(ctx, cep)
+	}
+	address, found, err := s.cache.Get(ctx, cep)
+	if err != nil {
+		slog.WarnContext(ctx, "Error reading CEP cache", "cep", cep, "error", err)
+	}
+	if found {
+		return address, nil
+	}
+	// Callers in this process share one lookup, so only that one contends
+	// for the cross-replica lease and polls the shared cache.
+	return doShared(ctx, &s.flight, cep, func(ctx context.Context) (*Address, error) {
+		return s.fetchAndCache(ctx, cep)
+	})
+}
+
+func (s *CEPService) fetchAndCache(ctx context.Context, cep string) (*Address, error) {
+	if leaser, ok := s.cache.(cepLeaser); ok {
+		address, release := s.awaitLease(ctx, leaser, cep)
+		if address != nil {
+			return address, nil
+		}
+		defer release()
+	}
+	address, err := s.resolve(ctx, cep)
+	if err != nil {
+		return nil, err
+	}
+	// The lookup already paid for the upstream call; cache it even if the
+	// caller has gone away meanwhile.
+	if err := s.cache.Set(context.WithoutCancel(ctx), cep, address); err != nil {
+		slog.WarnContext(ctx, "Error writing CEP cache", "cep", cep, "error", err)
+	}
+	return address, nil
+}
+
+// fetchCEPInfoShared collapses concurrent lookups of the same CEP into a
+// single upstream call whose result is shared by every caller.
+func (s *CEPService) fetchCEPInfoShared(ctx context.Context, cep string) (*Address, error) {
+	return doShared(ctx, &s.flight, cep, func(ctx context.Context) (*Address, error) {
+		return s.resolve(ctx, cep)
+	})
 }
 
-func (s *CEPService) GetCEPInfo(cep string) (*ViaCEPResponse, error) {
-	url := fmt.Sprintf("https://viacep.com.br/ws/%s/json/", cep)
-	resp, err := s.httpClient.Get(url)
+func (s *CEPService) fetchCEPInfo(ctx context.Context, cep string) (*Address, error) {
+	baseURL := s.endpoints.Current()
+	url := fmt.Sprintf("%s/ws/%s/json/", baseURL, cep)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.breaker.call(ctx, func() (*http.Response, error) {
+		return s.retry.do(ctx, "viacep", func() (*http.Response, error) {
+			return s.httpClient.Do(req)
+		})
+	})
 	if err != nil {
+		if ctx.Err() == nil && !errors.Is(err, ErrCircuitOpen) {
+			s.endpoints.MarkUnhealthy(baseURL)
+		}
 		return nil, err
 	}
 	defer resp.Body.Close()
@@ -116,15 +242,20 @@ func (s *CEPService) GetCEPInfo(cep string) (*ViaCEPResponse, error) {
 		return nil, err
 	}
 	if viaCEPResp.Erro {
-		return nil, fmt.Errorf("CEP not found")
+		return nil, ErrCEPNotFound
 	}
-	return &viaCEPResp, nil
+	return viaCEPResp.toAddress(), nil
 }
 
-func NewWeatherService(client HTTPClient, apiKey string) *WeatherService {
+func NewWeatherService(client HTTPClient, apiKey string, baseURLs ...string) *WeatherService {
+	if len(baseURLs) == 0 {
+		baseURLs = []string{defaultWeatherAPIBaseURL}
+	}
 	return &WeatherService{
 		httpClient: client,
 		apiKey:     apiKey,
+		endpoints:  NewEndpointPool(client, weatherAPIProbePath, baseURLs...),
+		quota:      newWeatherQuota(),
 	}
 }
 
@@ -138,15 +269,191 @@ func isMn(r rune) bool {
 	return unicode.Is(unicode.Mn, r)
 }
 
-func (s *WeatherService) GetTemperature(city, state string) (*WeatherAPIResponse, error) {
-	city = removeAccents(city)
-	query := fmt.Sprintf("%s,%s,Brazil", city, state)
-	url := fmt.Sprintf("https://api.weatherapi.com/v1/current.json?key=%s&q=%s&aqi=no", s.apiKey, query)
-	resp, err := s.httpClient.Get(url)
+func (s *WeatherService) GetTemperature(ctx context.Context, city, state string) (*Observation, error) {
+	observation, _, err := s.LookupTemperature(ctx, &Address{City: city, State: state})
+	return observation, err
+}
+
+// weatherCacheKey keys weather by municipality: every CEP of a city shares
+// one entry. The IBGE code is preferred; city/UF is the fallback for
+// addresses that lack it.
+func weatherCacheKey(address *Address) string {
+	if address.IBGE != "" {
+		return "ibge:" + address.IBGE
+	}
+	key := "city:" + strings.ToLower(removeAccents(address.City)) + "|" + strings.ToUpper(address.State)
+	if address.Country != "" && address.Country != defaultCountry {
+		key += "|" + address.Country
+	}
+	return key
+}
+
+const (
+	cacheStatusHit   = "HIT"
+	cacheStatusMiss  = "MISS"
+	cacheStatusStale = "STALE"
+)
+
+// LookupTemperature also reports where the observation came from: the cache,
+// upstream, or a stale cache entry whose refresh runs in the background.
+func (s *WeatherService) LookupTemperature(ctx context.Context, address *Address) (*Observation, string, error) {
+	return s.lookup(ctx, weatherCacheKey(address), weatherQuery(address))
+}
+
+// weatherLocation is what an observation is requested for: a municipality
+// or, with Point set, a specific coordinate. Country is an ISO code, empty
+// for Brazil.
+type weatherLocation struct {
+	City    string
+	State   string
+	Country string
+	Point   *coordinates
+}
+
+func weatherQuery(address *Address) weatherLocation {
+	return weatherLocation{City: address.City, State: address.State, Country: address.Country}
+}
+
+// weatherAPIQuery renders the location as WeatherAPI's q parameter.
+func (l weatherLocation) weatherAPIQuery() string {
+	if l.Point != nil {
+		return fmt.Sprintf("%.4f,%.4f", l.Point.Lat, l.Point.Lon)
+	}
+	return fmt.Sprintf("%s,%s,%s", removeAccents(l.City), l.State, l.countryName())
+}
+
+func (s *WeatherService) lookup(ctx context.Context, key string, query weatherLocation) (*Observation, string, error) {
+	if s.cache != nil {
+		if observation, fresh, ok := s.cache.GetStale(key); ok {
+			if fresh {
+				return observation, cacheStatusHit, nil
+			}
+			s.refreshing.Add(1)
+			go func() {
+				defer s.refreshing.Add(-1)
+				if _, err := s.refreshTemperature(context.Background(), key, query); err != nil {
+					slog.Warn("Error refreshing stale weather", "key", key, "error", redactURLError(err))
+				}
+			}()
+			return observation, cacheStatusStale, nil
+		}
+	}
+	observation, err := s.refreshTemperature(ctx, key, query)
+	if errors.Is(err, ErrWeatherQuotaExceeded) {
+		return s.lookupOverQuota(ctx, key, query, err)
+	}
+	if err != nil {
+		return nil, cacheStatusMiss, err
+	}
+	return observation, cacheStatusMiss, nil
+}
+
+// lookupOverQuota keeps answering while WeatherAPI refuses calls until the
+// quota resets: with the last observation cached for the location, however
+// old, or else with the quota fallback source.
+func (s *WeatherService) lookupOverQuota(ctx context.Context, key string, query weatherLocation, quotaErr error) (*Observation, string, error) {
+	if s.cache != nil {
+		if observation, ok := s.cache.Last(key); ok {
+			return observation, cacheStatusStale, nil
+		}
+	}
+	if s.quotaFallback == nil {
+		return nil, cacheStatusMiss, quotaErr
+	}
+	observation, err := doShared(ctx, &s.flight, "quota-fallback:"+key, func(ctx context.Context) (*Observation, error) {
+		observation, err := s.quotaFallback.Fetch(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		if s.cache != nil {
+			s.cache.Set(key, observation)
+		}
+		return observation, nil
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "Quota fallback failed", "key", key, "error", redactURLError(err))
+		return nil, cacheStatusMiss, quotaErr
+	}
+	return observation, cacheStatusMiss, nil
+}
+
+func (s *WeatherService) refreshTemperature(ctx context.Context, key string, query weatherLocation) (*Observation, error) {
+	return doShared(ctx, &s.flight, key, func(ctx context.Context) (*Observation, error) {
+		observation, err := s.fetch(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		if s.cache != nil {
+			s.cache.Set(key, observation)
+		}
+		return observation, nil
+	})
+}
+
+func (s *WeatherService) fetch(ctx context.Context, query weatherLocation) (*Observation, error) {
+	if s.source != nil {
+		return s.source.Fetch(ctx, query)
+	}
+	return s.fetchTemperature(ctx, query.weatherAPIQuery())
+}
+
+// fetchTemperature queries the current WeatherAPI endpoint, hedging to an
+// alternate endpoint when hedging is on and the first is slow.
+func (s *WeatherService) fetchTemperature(ctx context.Context, query string) (*Observation, error) {
+	baseURL := s.endpoints.Current()
+	alternate := s.endpoints.Alternate(baseURL)
+	if s.hedgeAfter <= 0 || alternate == "" {
+		return s.fetchTemperatureFrom(ctx, baseURL, query)
+	}
+	observation, _, err := hedge(ctx, "weatherapi", s.hedgeAfter, weatherLookupSettled,
+		func(ctx context.Context) (*Observation, error) { return s.fetchTemperatureFrom(ctx, baseURL, query) },
+		func(ctx context.Context) (*Observation, error) { return s.fetchTemperatureFrom(ctx, alternate, query) })
+	return observation, err
+}
+
+// weatherLookupSettled treats an exhausted quota as final: every endpoint
+// shares the same API key.
+func weatherLookupSettled(err error) bool {
+	return err == nil || errors.Is(err, ErrWeatherQuotaExceeded)
+}
+
+func (s *WeatherService) fetchTemperatureFrom(ctx context.Context, baseURL, query string) (*Observation, error) {
+	weatherResp, err := s.fetchForecastFrom(ctx, baseURL, query, 1, false)
+	if err != nil {
+		return nil, err
+	}
+	return weatherResp.toObservation(), nil
+}
+
+func (s *WeatherService) fetchForecastFrom(ctx context.Context, baseURL, query string, days int, withAlerts bool) (*WeatherAPIResponse, error) {
+	if s.quota.retryAfter() > 0 {
+		return nil, ErrWeatherQuotaExceeded
+	}
+	alerts := "no"
+	if withAlerts {
+		alerts = "yes"
+	}
+	url := fmt.Sprintf("%s/forecast.json?key=%s&q=%s&days=%d&aqi=no&alerts=%s", baseURL, neturl.QueryEscape(s.apiKey), neturl.QueryEscape(query), days, alerts)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
+	resp, err := s.breaker.call(ctx, func() (*http.Response, error) {
+		return s.retry.do(ctx, "weatherapi", func() (*http.Response, error) {
+			return s.httpClient.Do(req)
+		})
+	})
+	if err != nil {
+		if ctx.Err() == nil && !errors.Is(err, ErrCircuitOpen) {
+			s.endpoints.MarkUnhealthy(baseURL)
+		}
+		return nil, err
+	}
 	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusForbidden && isQuotaExceededBody(resp.Body) {
+		s.quota.markExhausted()
+		return nil, ErrWeatherQuotaExceeded
+	}
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("weather API error: %d", resp.StatusCode)
 	}
@@ -157,94 +464,429 @@ func (s *WeatherService) GetTemperature(city, state string) (*WeatherAPIResponse
 	return &weatherResp, nil
 }
 
+func writeRetryAfter(w http.ResponseWriter, d time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(d.Seconds()))))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	if e, ok := v.(ErrorResponse); ok && e.RequestID == "" {
+		e.RequestID = w.Header().Get(requestIDHeader)
+		v = e
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// resolveAddress validates the request's CEP and looks it up within the CEP
+// share of ctx's budget. On failure it writes the error response, or nothing
+// if the client went away, and returns false.
+func (app *App) resolveAddress(ctx context.Context, w http.ResponseWriter, r *http.Request) (string, *Address, bool) {
+	cep := mux.Vars(r)["cep"]
+	country := requestCountry(r.URL.Query().Get("country"))
+	resolver, ok := app.resolverFor(country)
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Message: "unsupported country"})
+		return "", nil, false
+	}
+	if !resolver.Valid(cep) {
+		app.metrics.lookupRejections.WithLabelValues("invalid_zipcode").Inc()
+		writeJSON(w, http.StatusUnprocessableEntity, ErrorResponse{Message: "invalid zipcode"})
+		return "", nil, false
+	}
+	normalizedCEP := resolver.Normalize(cep)
+	logger := slog.With("client_tag", clientTagFromContext(ctx), "cep", normalizedCEP)
+	cepCtx, cancelCEP := app.budget.cepContext(ctx)
+	cepInfo, err := resolver.Provider.GetCEPInfo(cepCtx, normalizedCEP)
+	cancelCEP()
+	if errors.Is(err, context.Canceled) {
+		logger.InfoContext(ctx, "Client disconnected during CEP lookup")
+		return "", nil, false
+	}
+	if errors.Is(err, ErrCircuitOpen) {
+		writeRetryAfter(w, resolver.Provider.RetryAfter(err))
+		writeJSON(w, http.StatusServiceUnavailable, ErrorResponse{Message: "cep provider unavailable"})
+		return "", nil, false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		logger.WarnContext(ctx, "CEP lookup exceeded its budget", "upstreams", upstreamCallsFrom(ctx).list())
+		writeJSON(w, http.StatusGatewayTimeout, ErrorResponse{Message: "upstream timeout"})
+		return "", nil, false
+	}
+	if err != nil {
+		if errors.Is(err, ErrCEPNotFound) {
+			app.metrics.lookupRejections.WithLabelValues("zipcode_not_found").Inc()
+		} else {
+			captureUpstreamError(ctx, err, "cep", normalizedCEP, "")
+		}
+		writeJSON(w, http.StatusNotFound, ErrorResponse{Message: "can not find zipcode"})
+		return "", nil, false
+	}
+	if country != defaultCountry && cepInfo.Country == "" {
+		withCountry := *cepInfo
+		withCountry.Country = country
+		cepInfo = &withCountry
+	}
+	return normalizedCEP, cepInfo, true
+}
+
 func (app *App) handleWeatherByCEP(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	cep := vars["cep"]
-	if !isValidCEP(cep) {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusUnprocessableEntity)
-		json.NewEncoder(w).Encode(ErrorResponse{Message: "invalid zipcode"})
+	override, err := parseCoordinateOverride(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Message: "invalid coordinates"})
 		return
 	}
-	normalizedCEP := normalizeCEP(cep)
-	cepInfo, err := app.cepService.GetCEPInfo(normalizedCEP)
-	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(ErrorResponse{Message: "can not find zipcode"})
+	start := time.Now()
+	ctx, upstreams := withUpstreamCalls(r.Context())
+	ctx, cancel := app.budget.start(ctx)
+	defer cancel()
+	normalizedCEP, cepInfo, ok := app.resolveAddress(ctx, w, r)
+	if !ok {
+		return
+	}
+	logger := slog.With("client_tag", clientTagFromContext(ctx), "cep", normalizedCEP)
+	weatherInfo, cacheStatus, err := app.weather.LookupTemperature(ctx, cepInfo)
+	var coordinatesIgnored bool
+	if err == nil && override != nil {
+		if withinMunicipality(*override, weatherInfo, app.coordinateRadiusKm) {
+			weatherInfo, cacheStatus, err = app.weather.LookupTemperatureAt(ctx, *override)
+		} else {
+			logger.InfoContext(ctx, "Coordinates outside municipality, using the city", "lat", override.Lat, "lon", override.Lon)
+			coordinatesIgnored = true
+		}
+	}
+	if errors.Is(err, context.Canceled) {
+		logger.InfoContext(ctx, "Client disconnected during weather lookup")
+		return
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		logger.WarnContext(ctx, "Weather lookup exceeded its budget", "city", cepInfo.City, "upstreams", upstreams.list())
+		writeJSON(w, http.StatusGatewayTimeout, ErrorResponse{Message: "upstream timeout"})
+		return
+	}
+	if errors.Is(err, ErrWeatherQuotaExceeded) {
+		writeRetryAfter(w, app.weather.RetryAfter(err))
+		writeJSON(w, http.StatusServiceUnavailable, ErrorResponse{Message: "weather provider quota exceeded"})
+		return
+	}
+	if errors.Is(err, ErrCircuitOpen) {
+		writeRetryAfter(w, app.weather.RetryAfter(err))
+		writeJSON(w, http.StatusServiceUnavailable, ErrorResponse{Message: "weather provider unavailable"})
 		return
 	}
-	weatherInfo, err := app.weatherService.GetTemperature(cepInfo.Localidade, cepInfo.UF)
 	if err != nil {
-		log.Printf("Error getting weather info: %v", err)
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(ErrorResponse{Message: "error getting weather information"})
+		logger.ErrorContext(ctx, "Error getting weather info", "city", cepInfo.City, "error", redactURLError(err), "upstreams", upstreams.list())
+		captureUpstreamError(ctx, err, app.weatherProvider, normalizedCEP, cepInfo.City)
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{Message: "error getting weather information"})
 		return
 	}
-	tempC := weatherInfo.Current.TempC
+	lang := requestLanguage(r)
+	tempC := weatherInfo.TempC
 	tempF := celsiusToFahrenheit(tempC)
 	tempK := celsiusToKelvin(tempC)
 	response := TemperatureResponse{
-		TempC: tempC,
-		TempF: tempF,
-		TempK: tempK,
+		TempC:              tempC,
+		TempF:              tempF,
+		TempK:              tempK,
+		Condition:          newConditionInfo(weatherInfo, lang),
+		UVAdvisory:         newUVAdvisory(weatherInfo, lang),
+		ChanceOfRain:       weatherInfo.ChanceOfRain,
+		CoordinatesIgnored: coordinatesIgnored,
 	}
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+	logger.InfoContext(ctx, "Weather lookup served", "city", cepInfo.City, "state", cepInfo.State, "cache", cacheStatus,
+		"latency_ms", time.Since(start).Milliseconds(), "upstreams", upstreams.list())
+	w.Header().Set("X-Cache", cacheStatus)
+	if wantsGeoJSON(r) {
+		writeGeoJSON(w, http.StatusOK, newGeoJSONFeature(cepInfo, weatherInfo, response))
+		return
+	}
+	writeJSON(w, http.StatusOK, response)
 }
 
 type App struct {
-	cepService     *CEPService
-	weatherService *WeatherService
+	cep                CEPProvider
+	weather            WeatherProvider
+	cepCache           CEPCache
+	weatherCache       *lruCache[*Observation]
+	forecastCache      *lruCache[*Forecast]
+	breakers           []*CircuitBreaker
+	clientTags         map[string]bool
+	viaCEPProxy        bool
+	cdnPolicy          CDNCachePolicy
+	adminToken         string
+	budget             deadlineBudget
+	invalidations      invalidationBus
+	coordinateRadiusKm float64
+	metrics            *Metrics
+	countries          map[string]PostalCodeResolver
+	weatherProvider    string
+	accessLog          *accessLogger
+	abuse              *abuseDetector
+	slo                *sloTracker
+	pprofAdmin         bool
+	validationBacklog  atomic.Int64
+	health             *deepHealth
+	suggestionRules    []SuggestionRule
+	energy             EnergyPolicy
+	riskProfiles       map[string]RiskWeights
+	internalAddr       string
 }
 
-func NewApp(cepService *CEPService, weatherService *WeatherService) *App {
+func NewApp(cep CEPProvider, weather WeatherProvider) *App {
 	return &App{
-		cepService:     cepService,
-		weatherService: weatherService,
+		cep:             cep,
+		weather:         weather,
+		metrics:         newMetrics(),
+		health:          newDeepHealth(),
+		suggestionRules: defaultSuggestionRules,
+		energy:          EnergyPolicy{BaseTempC: defaultEnergyBaseTempC, LoadPerDegreeDay: defaultEnergyLoadPerDegreeDay},
+		riskProfiles:    defaultRiskProfiles,
 	}
 }
 
-func (app *App) setupRoutes() http.Handler {
-	r := mux.NewRouter()
-	r.HandleFunc("/weather/{cep}", app.handleWeatherByCEP).Methods("GET")
-	return r
+// buildApp wires services, caches and background health checks from cfg.
+// The background work is registered on the returned Lifecycle; nothing runs
+// until it is started, and stopping it releases the resources in reverse
+// dependency order.
+func buildApp(cfg Config, httpClient HTTPClient) (*App, *Lifecycle) {
+	lifecycle := NewLifecycle(4)
+	lifecycle.Register(tracingComponent(cfg))
+	lifecycle.Register(sentryComponent(cfg))
+	if cfg.PprofAddr != "" {
+		lifecycle.Register(pprofComponent(cfg.PprofAddr))
+	}
+	metrics := newMetrics()
+	httpClient = tracingHTTPClient{next: metricsHTTPClient{next: httpClient, metrics: metrics, upstreams: upstreamNames(cfg)}}
+	cepService := NewCEPService(httpClient, cfg.ViaCEPBaseURLs...)
+	weatherService := NewWeatherService(httpClient, cfg.WeatherAPIKey, cfg.WeatherAPIBaseURLs...)
+	var redisClient *redis.Client
+	if cfg.RedisAddr != "" {
+		redisClient = redis.NewClient(&redis.Options{
+			Addr:     cfg.RedisAddr,
+			Password: cfg.RedisPassword,
+			DB:       cfg.RedisDB,
+		})
+		lifecycle.Register(Component{
+			Name: "redis",
+			Stop: func(ctx context.Context) error { return redisClient.Close() },
+		})
+		redisCache := NewRedisCEPCache(redisClient, cfg.CEPCacheTTL)
+		redisCache.jitter = cfg.CacheTTLJitter
+		cepService.cache = redisCache
+		slog.Info("CEP cache enabled", "redis", cfg.RedisAddr, "ttl", cfg.CEPCacheTTL)
+		if cfg.CEPCacheL1Size > 0 {
+			cepService.cache = NewTieredCEPCache(cepService.cache, cfg.CEPCacheL1Size, cfg.CEPCacheL1TTL)
+			slog.Info("CEP L1 cache enabled", "size", cfg.CEPCacheL1Size, "ttl", cfg.CEPCacheL1TTL)
+		}
+	}
+	if cfg.WeatherCacheSize > 0 {
+		weatherService.cache = newLRUCache[*Observation](cfg.WeatherCacheSize, cfg.WeatherCacheTTL)
+		weatherService.cache.staleTTL = cfg.WeatherCacheStaleTTL
+		weatherService.cache.sizeOf = observationSize
+		weatherService.cache.jitter = cfg.CacheTTLJitter
+		weatherService.cache.keepExpired = true
+		weatherService.forecasts = newLRUCache[*Forecast](cfg.WeatherCacheSize, cfg.WeatherCacheTTL)
+		weatherService.forecasts.staleTTL = cfg.WeatherCacheStaleTTL
+		weatherService.forecasts.jitter = cfg.CacheTTLJitter
+	}
+	weatherService.quota.exhaustions = metrics.quotaExhausted.WithLabelValues(weatherProviderWeatherAPI)
+	cepService.retry = cfg.ViaCEPRetry
+	cepService.retry.retries = metrics.upstreamRetries
+	weatherService.retry = cfg.WeatherAPIRetry
+	weatherService.retry.retries = metrics.upstreamRetries
+	cepService.hedgeAfter = cfg.HedgeAfter
+	weatherService.hedgeAfter = cfg.HedgeAfter
+	weatherProvider := weatherProviderWeatherAPI
+	if cfg.WeatherProvider == weatherProviderOpenMeteo {
+		weatherProvider = weatherProviderOpenMeteo
+	}
+	if cfg.BreakerThreshold > 0 {
+		cepService.breaker = NewCircuitBreaker("viacep", cfg.BreakerThreshold, cfg.BreakerCooldown)
+		weatherService.breaker = NewCircuitBreaker(weatherProvider, cfg.BreakerThreshold, cfg.BreakerCooldown)
+	}
+	if weatherProvider == weatherProviderOpenMeteo {
+		source := NewOpenMeteoSource(httpClient, cfg.OpenMeteoGeocodingURL, cfg.OpenMeteoForecastURL)
+		source.retry = weatherService.retry
+		source.breaker = weatherService.breaker
+		weatherService.source = source
+	}
+	var quotaFallbackBreaker *CircuitBreaker
+	if weatherService.source == nil && cfg.WeatherQuotaFallback == weatherProviderOpenMeteo {
+		fallback := NewOpenMeteoSource(httpClient, cfg.OpenMeteoGeocodingURL, cfg.OpenMeteoForecastURL)
+		fallback.retry = weatherService.retry
+		if cfg.BreakerThreshold > 0 {
+			quotaFallbackBreaker = NewCircuitBreaker(weatherProviderOpenMeteo, cfg.BreakerThreshold, cfg.BreakerCooldown)
+			fallback.breaker = quotaFallbackBreaker
+		}
+		weatherService.quotaFallback = fallback
+	}
+	for _, name := range cfg.CEPFallbackProviders {
+		resolver, err := newCEPResolver(name, httpClient, cfg)
+		if err != nil {
+			slog.Warn("Ignoring CEP fallback", "error", err)
+			continue
+		}
+		if cfg.BreakerThreshold > 0 {
+			resolver.breaker = NewCircuitBreaker(name, cfg.BreakerThreshold, cfg.BreakerCooldown)
+		}
+		cepService.fallbacks = append(cepService.fallbacks, resolver)
+	}
+	lifecycle.Register(cepService.endpoints.component("viacep-health", cfg.EndpointHealthInterval))
+	if weatherService.source == nil {
+		lifecycle.Register(weatherService.endpoints.component("weatherapi-health", cfg.EndpointHealthInterval))
+	}
+
+	app := NewApp(cepService, weatherService)
+	app.metrics = metrics
+	app.cepCache = cepService.cache
+	app.weatherCache = weatherService.cache
+	app.forecastCache = weatherService.forecasts
+	for _, breaker := range []*CircuitBreaker{cepService.breaker, weatherService.breaker, quotaFallbackBreaker} {
+		if breaker != nil {
+			app.breakers = append(app.breakers, breaker)
+		}
+	}
+	for _, fallback := range cepService.fallbacks {
+		if resolver, ok := fallback.(*httpCEPResolver); ok && resolver.breaker != nil {
+			app.breakers = append(app.breakers, resolver.breaker)
+		}
+	}
+	for _, breaker := range app.breakers {
+		// A provider listed twice in CEP_FALLBACK_PROVIDERS has two breakers
+		// under one name; only the first is exported.
+		if err := metrics.registry.Register(breaker.collector()); err != nil {
+			slog.Warn("Ignoring duplicate circuit breaker metric", "error", err)
+		}
+	}
+	app.clientTags = parseClientTagAllowlist(cfg.ClientTagAllowlist)
+	app.viaCEPProxy = cfg.ViaCEPProxyEnabled
+	app.cdnPolicy = cfg.CDNPolicy
+	app.adminToken = cfg.AdminToken
+	app.budget = deadlineBudget{total: cfg.RequestBudget, cepShare: cfg.CEPBudgetShare}
+	app.coordinateRadiusKm = cfg.CoordinateRadiusKm
+	app.weatherProvider = cfg.WeatherProvider
+	app.pprofAdmin = cfg.PprofAdmin
+	if cfg.SuggestionRules != nil {
+		app.suggestionRules = cfg.SuggestionRules
+	}
+	app.energy = cfg.Energy
+	app.internalAddr = cfg.InternalAddr
+	if cfg.RiskProfiles != nil {
+		app.riskProfiles = cfg.RiskProfiles
+	}
+	registerDependencyChecks(app.health, cfg, httpClient, cepService, weatherService, weatherProvider, redisClient)
+	if cfg.AccessLog != accessLogOff {
+		app.accessLog = newAccessLogger(cfg.AccessLog, os.Stdout)
+		app.accessLog.clientTag = app.requestClientTag
+		app.accessLog.proxies = cfg.TrustedProxies
+	}
+	if cfg.Abuse.Window > 0 {
+		app.abuse = newAbuseDetector(cfg.Abuse)
+		app.abuse.proxies = cfg.TrustedProxies
+	}
+	if cfg.SLO.LatencyTarget > 0 {
+		app.slo = newSLOTracker(cfg.SLO)
+		metrics.registry.MustRegister(app.slo.collectors()...)
+	}
+	if cfg.Watchdog.Interval > 0 {
+		watchdog := newWatchdog(cfg.Watchdog, metrics)
+		watchdog.watchQueue("weather_refresh", func() int { return int(weatherService.refreshing.Load()) })
+		watchdog.watchQueue("address_validation", func() int { return int(app.validationBacklog.Load()) })
+		lifecycle.Register(watchdog.component())
+	}
+	if redisClient != nil {
+		bus := NewRedisInvalidationBus(redisClient)
+		app.invalidations = bus
+		var cancel context.CancelFunc
+		lifecycle.Register(Component{
+			Name:      "cache-invalidation",
+			DependsOn: []string{"redis"},
+			Start: func(ctx context.Context) error {
+				var subCtx context.Context
+				subCtx, cancel = context.WithCancel(context.Background())
+				// Without the subscription this replica only misses remote
+				// purges; that is not worth refusing to start over.
+				if err := bus.Subscribe(subCtx, app.applyInvalidation); err != nil {
+					slog.Error("Error subscribing to cache invalidations", "error", err)
+				}
+				return nil
+			},
+			Stop: func(ctx context.Context) error {
+				cancel()
+				return nil
+			},
+		})
+	}
+	return app, lifecycle
 }
 
 func main() {
 	godotenv.Load()
-	viper.AutomaticEnv()
-	weatherAPIKey := viper.GetString("WEATHER_API_KEY")
-	if weatherAPIKey == "" {
-		log.Fatal("WEATHER_API_KEY environment variable is required")
+	if len(os.Args) > 1 && os.Args[1] == "check" {
+		os.Exit(runCheck(os.Args[2:], os.Stdout))
 	}
-	port := viper.GetString("PORT")
-	if port == "" {
-		port = "8080"
+	cfg, err := loadConfig()
+	if err != nil {
+		fatal("Invalid configuration", "error", err)
+	}
+	logger, err := newLogger(os.Stderr, cfg.LogLevel, cfg.LogFormat)
+	if err != nil {
+		fatal("Invalid logging configuration", "error", err)
 	}
+	slog.SetDefault(logger)
 
-	log.Printf("Starting application with configuration:")
-	log.Printf("PORT: %s", port)
-	log.Printf("WEATHER_API_KEY: %s", weatherAPIKey[:4]+"..."+weatherAPIKey[len(weatherAPIKey)-4:])
+	slog.Info("Starting application", "port", cfg.Port, "weather_provider", cfg.WeatherProvider,
+		"weather_api_key", maskSecret(cfg.WeatherAPIKey))
 
-	httpClient := &http.Client{}
-	cepService := NewCEPService(httpClient)
-	weatherService := NewWeatherService(httpClient, weatherAPIKey)
-	app := NewApp(cepService, weatherService)
+	app, lifecycle := buildApp(cfg, newHTTPClient(cfg))
+	var tlsConfig *tls.Config
+	if cfg.TLS.enabled() {
+		var challenge http.Handler
+		tlsConfig, challenge, err = newTLSConfig(cfg.TLS)
+		if err != nil {
+			fatal("Invalid TLS configuration", "error", err)
+		}
+		if cfg.TLS.RedirectAddr != "" {
+			lifecycle.Register(redirectComponent(cfg.TLS.RedirectAddr, challenge, cfg.Server))
+		}
+		slog.Info("TLS enabled", "autocert_domains", cfg.TLS.AutocertDomains)
+	}
+	if cfg.InternalAddr != "" {
+		lifecycle.Register(internalComponent(cfg.InternalAddr, app.setupInternalRoutes(), cfg))
+	}
+	if err := lifecycle.Start(context.Background()); err != nil {
+		fatal("Error starting components", "error", err)
+	}
+	if len(cfg.WarmupCEPs) > 0 {
+		warmed := app.warmUp(context.Background(), cfg.WarmupCEPs)
+		slog.Info("Warm-up finished", "warmed", warmed, "configured", len(cfg.WarmupCEPs))
+	}
 	router := app.setupRoutes()
 
-	addr := ":" + port
-	log.Printf("Server starting on %s", addr)
+	slog.Info("Server starting", "addr", cfg.ListenAddr)
 
-	server := &http.Server{
-		Addr:    addr,
-		Handler: router,
+	server := newHTTPServer(cfg.ListenAddr, router, cfg.Server)
+	server.TLSConfig = tlsConfig
+	ln, err := listen(cfg.ListenAddr, cfg.ListenSocketMode)
+	if err != nil {
+		fatal("Server failed to start", "error", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	serveErr := serve(ctx, server, ln, cfg.ShutdownTimeout)
+	if serveErr != nil {
+		slog.Error("Server failed", "error", serveErr)
 	}
 
-	log.Printf("Server configured and ready to accept connections")
-	if err := server.ListenAndServe(); err != nil {
-		log.Fatalf("Server failed to start: %v", err)
+	// Flushes traces and Sentry events and closes Redis.
+	stopCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := lifecycle.Stop(stopCtx); err != nil {
+		slog.Error("Error stopping components", "error", err)
+	}
+	if serveErr != nil {
+		os.Exit(1)
 	}
+	slog.Info("Server stopped")
 }
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"io"
@@ -36,7 +37,8 @@ func (m *MockHTTPClient) AddError(url string, err error) {
 	m.errors[url] = err
 }
 
-func (m *MockHTTPClient) Get(url string) (*http.Response, error) {
+func (m *MockHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	url := req.URL.String()
 	if err, exists := m.errors[url]; exists {
 		return nil, err
 	}
@@ -146,18 +148,18 @@ func TestCEPService_GetCEPInfo(t *testing.T) {
 		}`
 		mockClient.AddResponse("https://viacep.com.br/ws/01310100/json/", 200, cepResponse)
 
-		result, err := service.GetCEPInfo("01310100")
+		result, err := service.GetCEPInfo(context.Background(), "01310100")
 
 		if err != nil {
 			t.Errorf("Expected no error, got %v", err)
 		}
 
-		if result.Localidade != "São Paulo" {
-			t.Errorf("Expected localidade 'São Paulo', got '%s'", result.Localidade)
+		if result.City != "São Paulo" {
+			t.Errorf("Expected city 'São Paulo', got '%s'", result.City)
 		}
 
-		if result.UF != "SP" {
-			t.Errorf("Expected UF 'SP', got '%s'", result.UF)
+		if result.State != "SP" {
+			t.Errorf("Expected state 'SP', got '%s'", result.State)
 		}
 	})
 
@@ -165,7 +167,7 @@ func TestCEPService_GetCEPInfo(t *testing.T) {
 		cepResponse := `{"erro": true}`
 		mockClient.AddResponse("https://viacep.com.br/ws/99999999/json/", 200, cepResponse)
 
-		result, err := service.GetCEPInfo("99999999")
+		result, err := service.GetCEPInfo(context.Background(), "99999999")
 
 		if err == nil {
 			t.Error("Expected error for non-existent CEP")
@@ -179,7 +181,7 @@ func TestCEPService_GetCEPInfo(t *testing.T) {
 	t.Run("Erro de conexão", func(t *testing.T) {
 		mockClient.AddError("https://viacep.com.br/ws/12345678/json/", errors.New("connection error"))
 
-		result, err := service.GetCEPInfo("12345678")
+		result, err := service.GetCEPInfo(context.Background(), "12345678")
 
 		if err == nil {
 			t.Error("Expected connection error")
@@ -220,29 +222,29 @@ func TestWeatherService_GetTemperature(t *testing.T) {
 				}
 			}
 		}`
-		expectedURL := "https://api.weatherapi.com/v1/current.json?key=test-api-key&q=São Paulo,SP,Brazil&aqi=no"
+		expectedURL := "https://api.weatherapi.com/v1/forecast.json?key=test-api-key&q=Sao+Paulo%2CSP%2CBrazil&days=1&aqi=no&alerts=no"
 		mockClient.AddResponse(expectedURL, 200, weatherResponse)
 
-		result, err := service.GetTemperature("São Paulo", "SP")
+		result, err := service.GetTemperature(context.Background(), "São Paulo", "SP")
 
 		if err != nil {
 			t.Errorf("Expected no error, got %v", err)
 		}
 
-		if result.Current.TempC != 25.0 {
-			t.Errorf("Expected temperature 25.0°C, got %.1f°C", result.Current.TempC)
+		if result.TempC != 25.0 {
+			t.Errorf("Expected temperature 25.0°C, got %.1f°C", result.TempC)
 		}
 
-		if result.Location.Name != "São Paulo" {
-			t.Errorf("Expected location 'São Paulo', got '%s'", result.Location.Name)
+		if result.City != "São Paulo" {
+			t.Errorf("Expected location 'São Paulo', got '%s'", result.City)
 		}
 	})
 
 	t.Run("Erro da API do clima", func(t *testing.T) {
-		expectedURL := "https://api.weatherapi.com/v1/current.json?key=test-api-key&q=Invalid City,XX,Brazil&aqi=no"
+		expectedURL := "https://api.weatherapi.com/v1/forecast.json?key=test-api-key&q=Invalid+City%2CXX%2CBrazil&days=1&aqi=no&alerts=no"
 		mockClient.AddResponse(expectedURL, 400, `{"error": {"code": 1006, "message": "No matching location found."}}`)
 
-		result, err := service.GetTemperature("Invalid City", "XX")
+		result, err := service.GetTemperature(context.Background(), "Invalid City", "XX")
 
 		if err == nil {
 			t.Error("Expected error for invalid location")
@@ -299,7 +301,7 @@ func TestHandleWeatherByCEP(t *testing.T) {
 				}
 			}
 		}`
-		weatherURL := "https://api.weatherapi.com/v1/current.json?key=test-api-key&q=São Paulo,SP,Brazil&aqi=no"
+		weatherURL := "https://api.weatherapi.com/v1/forecast.json?key=test-api-key&q=Sao+Paulo%2CSP%2CBrazil&days=1&aqi=no&alerts=no"
 		mockClient.AddResponse(weatherURL, 200, weatherResponse)
 
 		req, err := http.NewRequest("GET", "/weather/01310-100", nil)
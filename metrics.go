@@ -0,0 +1,175 @@
+package main
+
+import (
+	"net/http"
+	neturl "net/url"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics owns a private registry so every App (and every test) gets its
+// own counters instead of sharing the process-wide default registry.
+type Metrics struct {
+	registry         *prometheus.Registry
+	requests         *prometheus.CounterVec
+	requestErrors    *prometheus.CounterVec
+	requestDuration  *prometheus.HistogramVec
+	upstreamDuration *prometheus.HistogramVec
+	upstreamErrors   *prometheus.CounterVec
+	lookupRejections *prometheus.CounterVec
+	quotaExhausted   *prometheus.CounterVec
+	upstreamRetries  *prometheus.CounterVec
+}
+
+func newMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "projetodeploy_http_requests_total",
+			Help: "HTTP requests served, by route, method, status code and client tag.",
+		}, []string{"route", "method", "code", "client_tag"}),
+		requestErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "projetodeploy_http_request_errors_total",
+			Help: "HTTP requests answered with a 5xx status, by route, method and client tag.",
+		}, []string{"route", "method", "client_tag"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "projetodeploy_http_request_duration_seconds",
+			Help:    "HTTP request latency, by route and method.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+		upstreamDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "projetodeploy_upstream_request_duration_seconds",
+			Help:    "Duration of calls to external providers, by provider and status class (2xx, 4xx, 5xx, error).",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"upstream", "outcome"}),
+		upstreamErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "projetodeploy_upstream_errors_total",
+			Help: "Calls to external providers that failed with a network error or a 5xx status.",
+		}, []string{"upstream"}),
+		lookupRejections: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "projetodeploy_weather_lookup_rejections_total",
+			Help: "Weather lookups refused because of the caller's input: invalid_zipcode (422) or zipcode_not_found (404).",
+		}, []string{"reason"}),
+		quotaExhausted: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "projetodeploy_upstream_quota_exhausted_total",
+			Help: "Times a provider rejected us for an exhausted call quota; calls stay suspended until the quota resets.",
+		}, []string{"provider"}),
+		upstreamRetries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "projetodeploy_upstream_retries_total",
+			Help: "Upstream calls retried after a network error or a 5xx, by provider.",
+		}, []string{"provider"}),
+	}
+	m.registry.MustRegister(
+		m.requests, m.requestErrors, m.requestDuration, m.upstreamDuration, m.upstreamErrors, m.lookupRejections, m.quotaExhausted, m.upstreamRetries,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+func (m *Metrics) handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusRecorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+// middleware is installed on the router, so it runs for every registered
+// route and labels by route template rather than by raw path. clientTag
+// must return a bounded set of values.
+func (m *Metrics) middleware(clientTag func(*http.Request) string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := "unknown"
+			if current := mux.CurrentRoute(r); current != nil {
+				if template, err := current.GetPathTemplate(); err == nil {
+					route = template
+				}
+			}
+			start := time.Now()
+			recorder := &statusRecorder{ResponseWriter: w}
+			next.ServeHTTP(recorder, r)
+			if recorder.status == 0 {
+				recorder.status = http.StatusOK
+			}
+			tag := clientTag(r)
+			m.requests.WithLabelValues(route, r.Method, strconv.Itoa(recorder.status), tag).Inc()
+			m.requestDuration.WithLabelValues(route, r.Method).Observe(time.Since(start).Seconds())
+			if recorder.status >= http.StatusInternalServerError {
+				m.requestErrors.WithLabelValues(route, r.Method, tag).Inc()
+			}
+		})
+	}
+}
+
+// metricsHTTPClient times every upstream call. Upstreams are labelled by
+// provider name, falling back to the host, which is bounded by the
+// configured base URLs anyway.
+type metricsHTTPClient struct {
+	next      HTTPClient
+	metrics   *Metrics
+	upstreams map[string]string
+}
+
+// upstreamNames maps each configured upstream host to its provider, so all
+// mirrors of a provider share one series.
+func upstreamNames(cfg Config) map[string]string {
+	names := make(map[string]string)
+	add := func(name string, rawURLs ...string) {
+		for _, raw := range rawURLs {
+			if u, err := neturl.Parse(raw); err == nil && u.Host != "" {
+				names[u.Host] = name
+			}
+		}
+	}
+	add("viacep", cfg.ViaCEPBaseURLs...)
+	add(weatherProviderWeatherAPI, cfg.WeatherAPIBaseURLs...)
+	add("brasilapi", cfg.BrasilAPIBaseURL)
+	add("awesomeapi", cfg.AwesomeAPIBaseURL)
+	add(weatherProviderOpenMeteo, cfg.OpenMeteoGeocodingURL, cfg.OpenMeteoForecastURL)
+	return names
+}
+
+func (c metricsHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	upstream, ok := c.upstreams[req.URL.Host]
+	if !ok {
+		upstream = req.URL.Host
+	}
+	start := time.Now()
+	resp, err := c.next.Do(req)
+	outcome, status := "error", "error"
+	if err == nil {
+		outcome = strconv.Itoa(resp.StatusCode/100) + "xx"
+		status = strconv.Itoa(resp.StatusCode)
+	}
+	recordUpstreamCall(req.Context(), upstream, status)
+	c.metrics.upstreamDuration.WithLabelValues(upstream, outcome).Observe(time.Since(start).Seconds())
+	if err != nil || resp.StatusCode >= http.StatusInternalServerError {
+		c.metrics.upstreamErrors.WithLabelValues(upstream).Inc()
+	}
+	return resp, err
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestE2E_Metrics(t *testing.T) {
+	env := newE2EEnv(t, func(cfg *Config) { cfg.ClientTagAllowlist = "mobile" })
+	env.get(t, "/weather/01310100", nil)
+	env.get(t, "/weather/01310100", map[string]string{clientTagHeader: "mobile"})
+	env.get(t, "/weather/01310100", map[string]string{clientTagHeader: "scraper"})
+	env.get(t, "/weather/123", nil)
+	env.get(t, "/weather/99999999", nil)
+	env.weatherAPI.set(func(f *fakeWeatherAPI) { f.status = http.StatusBadGateway })
+	env.get(t, "/weather/20040002", nil)
+
+	resp, body := env.get(t, "/metrics", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"Requisição bem-sucedida por rota", `projetodeploy_http_requests_total{client_tag="unknown",code="200",method="GET",route="/weather/{cep}"} 1`},
+		{"Tag de cliente na lista", `projetodeploy_http_requests_total{client_tag="mobile",code="200",method="GET",route="/weather/{cep}"} 1`},
+		{"Tag de cliente fora da lista", `projetodeploy_http_requests_total{client_tag="other",code="200",method="GET",route="/weather/{cep}"} 1`},
+		{"CEP inválido", `projetodeploy_http_requests_total{client_tag="unknown",code="422",method="GET",route="/weather/{cep}"} 1`},
+		{"Erro do servidor", `projetodeploy_http_request_errors_total{client_tag="unknown",method="GET",route="/weather/{cep}"} 1`},
+		{"Histograma de latência por rota", `projetodeploy_http_request_duration_seconds_count{method="GET",route="/weather/{cep}"} 6`},
+		{"Duração das chamadas à ViaCEP", `projetodeploy_upstream_request_duration_seconds_count{outcome="2xx",upstream="viacep"} 5`},
+		{"CEP inválido contado à parte", `projetodeploy_weather_lookup_rejections_total{reason="invalid_zipcode"} 1`},
+		{"CEP inexistente contado à parte", `projetodeploy_weather_lookup_rejections_total{reason="zipcode_not_found"} 1`},
+		{"Erros da WeatherAPI", `projetodeploy_upstream_errors_total{upstream="weatherapi"}`},
+		{"Erros da WeatherAPI por classe de status", `projetodeploy_upstream_request_duration_seconds_count{outcome="5xx",upstream="weatherapi"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.Contains(string(body), tt.expected) {
+				t.Errorf("Expected metrics to contain %q", tt.expected)
+			}
+		})
+	}
+}
+
+func TestUpstreamNames(t *testing.T) {
+	names := upstreamNames(Config{
+		ViaCEPBaseURLs:     []string{"https://viacep.com.br", "https://viacep-mirror.internal:8443"},
+		WeatherAPIBaseURLs: []string{"https://api.weatherapi.com"},
+		BrasilAPIBaseURL:   "https://brasilapi.com.br",
+	})
+
+	tests := []struct {
+		host     string
+		expected string
+	}{
+		{"viacep.com.br", "viacep"},
+		{"viacep-mirror.internal:8443", "viacep"},
+		{"api.weatherapi.com", "weatherapi"},
+		{"brasilapi.com.br", "brasilapi"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			if got := names[tt.host]; got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestE2E_MetricsQuotaExhausted(t *testing.T) {
+	env := newE2EEnv(t, nil)
+	env.weatherAPI.set(func(f *fakeWeatherAPI) { f.quotaExceeded = true })
+	env.get(t, "/weather/01310100", nil)
+
+	_, body := env.get(t, "/metrics", nil)
+	expected := `projetodeploy_upstream_quota_exhausted_total{provider="weatherapi"} 1`
+	if !strings.Contains(string(body), expected) {
+		t.Errorf("Expected metrics to contain %q", expected)
+	}
+}
+
+func TestE2E_MetricsRetries(t *testing.T) {
+	env := newE2EEnv(t, func(cfg *Config) {
+		cfg.WeatherAPIRetry = RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}
+	})
+	env.weatherAPI.set(func(f *fakeWeatherAPI) { f.status = http.StatusBadGateway })
+	env.get(t, "/weather/01310100", nil)
+
+	_, body := env.get(t, "/metrics", nil)
+	expected := `projetodeploy_upstream_retries_total{provider="weatherapi"} 2`
+	if !strings.Contains(string(body), expected) {
+		t.Errorf("Expected metrics to contain %q", expected)
+	}
+}
+
+func TestE2E_MetricsBreakerState(t *testing.T) {
+	env := newE2EEnv(t, func(cfg *Config) {
+		cfg.BreakerThreshold = 1
+		cfg.BreakerCooldown = time.Minute
+	})
+	env.weatherAPI.set(func(f *fakeWeatherAPI) { f.status = http.StatusBadGateway })
+	env.get(t, "/weather/01310100", nil)
+
+	_, body := env.get(t, "/metrics", nil)
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"Breaker aberto", `projetodeploy_circuit_breaker_state{upstream="weatherapi"} 2`},
+		{"Breaker fechado", `projetodeploy_circuit_breaker_state{upstream="viacep"} 0`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.Contains(string(body), tt.expected) {
+				t.Errorf("Expected metrics to contain %q", tt.expected)
+			}
+		})
+	}
+}
+
+func TestE2E_LookupRejectionsAcrossRoutes(t *testing.T) {
+	env := newE2EEnv(t, nil)
+	routes := []string{"/weather/", "/suggestion/", "/delivery-window/"}
+
+	tests := []struct {
+		name     string
+		cep      string
+		expected int
+	}{
+		{"CEP inválido", "123", http.StatusUnprocessableEntity},
+		{"CEP inexistente", "99999999", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		for _, route := range routes {
+			t.Run(tt.name+" em "+route, func(t *testing.T) {
+				resp, body := env.get(t, route+tt.cep, nil)
+				if resp.StatusCode != tt.expected {
+					t.Errorf("Expected status %d, got %d: %s", tt.expected, resp.StatusCode, body)
+				}
+			})
+		}
+	}
+
+	_, body := env.get(t, "/metrics", nil)
+	for _, expected := range []string{
+		`projetodeploy_weather_lookup_rejections_total{reason="invalid_zipcode"} 3`,
+		`projetodeploy_weather_lookup_rejections_total{reason="zipcode_not_found"} 3`,
+	} {
+		if !strings.Contains(string(body), expected) {
+			t.Errorf("Expected metrics to contain %q", expected)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"time"
+)
+
+const (
+	weatherProviderWeatherAPI = "weatherapi"
+	weatherProviderOpenMeteo  = "open-meteo"
+
+	defaultOpenMeteoGeocodingURL = "https://geocoding-api.open-meteo.com"
+	defaultOpenMeteoForecastURL  = "https://api.open-meteo.com"
+)
+
+// WeatherSource is an upstream that produces observations. WeatherService
+// layers caching, staleness and request coalescing on top of it.
+type WeatherSource interface {
+	Fetch(ctx context.Context, loc weatherLocation) (*Observation, error)
+}
+
+// brazilianStates maps UFs to the state names Open-Meteo reports as admin1,
+// so a city name shared by several states resolves to the right one.
+var brazilianStates = map[string]string{
+	"AC": "Acre", "AL": "Alagoas", "AP": "Amapá", "AM": "Amazonas", "BA": "Bahia",
+	"CE": "Ceará", "DF": "Distrito Federal", "ES": "Espírito Santo", "GO": "Goiás",
+	"MA": "Maranhão", "MT": "Mato Grosso", "MS": "Mato Grosso do Sul", "MG": "Minas Gerais",
+	"PA": "Pará", "PB": "Paraíba", "PR": "Paraná", "PE": "Pernambuco", "PI": "Piauí",
+	"RJ": "Rio de Janeiro", "RN": "Rio Grande do Norte", "RS": "Rio Grande do Sul",
+	"RO": "Rondônia", "RR": "Roraima", "SC": "Santa Catarina", "SP": "São Paulo",
+	"SE": "Sergipe", "TO": "Tocantins",
+}
+
+type openMeteoGeocodingResponse struct {
+	Results []struct {
+		Name      string  `json:"name"`
+		Latitude  float64 `json:"latitude"`
+		Longitude float64 `json:"longitude"`
+		Country   string  `json:"country"`
+		Admin1    string  `json:"admin1"`
+	} `json:"results"`
+}
+
+type openMeteoForecastResponse struct {
+	Current struct {
+		Time                     int64   `json:"time"`
+		Temperature2m            float64 `json:"temperature_2m"`
+		IsDay                    int     `json:"is_day"`
+		WeatherCode              int     `json:"weather_code"`
+		UVIndex                  float64 `json:"uv_index"`
+		PrecipitationProbability *int    `json:"precipitation_probability"`
+	} `json:"current"`
+}
+
+// OpenMeteoSource needs no API key: cities are geocoded first and the
+// forecast is then requested for the resulting point.
+type OpenMeteoSource struct {
+	httpClient   HTTPClient
+	geocodingURL string
+	forecastURL  string
+	retry        RetryPolicy
+	breaker      *CircuitBreaker
+}
+
+func NewOpenMeteoSource(client HTTPClient, geocodingURL, forecastURL string) *OpenMeteoSource {
+	return &OpenMeteoSource{
+		httpClient:   client,
+		geocodingURL: strings.TrimRight(geocodingURL, "/"),
+		forecastURL:  strings.TrimRight(forecastURL, "/"),
+	}
+}
+
+func (s *OpenMeteoSource) Fetch(ctx context.Context, loc weatherLocation) (*Observation, error) {
+	observation := &Observation{}
+	point := loc.Point
+	if point == nil {
+		var err error
+		if point, err = s.geocode(ctx, loc, observation); err != nil {
+			return nil, err
+		}
+	}
+	query := neturl.Values{
+		"latitude":   {fmt.Sprintf("%.4f", point.Lat)},
+		"longitude":  {fmt.Sprintf("%.4f", point.Lon)},
+		"current":    {"temperature_2m,is_day,weather_code,uv_index,precipitation_probability"},
+		"timeformat": {"unixtime"},
+	}
+	var forecast openMeteoForecastResponse
+	if err := s.get(ctx, s.forecastURL+"/v1/forecast?"+query.Encode(), &forecast); err != nil {
+		return nil, err
+	}
+	observation.Lat, observation.Lon = point.Lat, point.Lon
+	observation.TempC = forecast.Current.Temperature2m
+	observation.IsDay = forecast.Current.IsDay == 1
+	observation.UV = forecast.Current.UVIndex
+	observation.ChanceOfRain = forecast.Current.PrecipitationProbability
+	observation.Condition = conditionFromWMOCode(forecast.Current.WeatherCode)
+	observation.ObservedAt = time.Unix(forecast.Current.Time, 0).UTC()
+	return observation, nil
+}
+
+// geocode resolves the city to a point, preferring the result in loc's state,
+// and records the place names on observation.
+func (s *OpenMeteoSource) geocode(ctx context.Context, loc weatherLocation, observation *Observation) (*coordinates, error) {
+	query := neturl.Values{
+		"name":        {loc.City},
+		"count":       {"10"},
+		"language":    {"pt"},
+		"countryCode": {strings.ToUpper(loc.country())},
+	}
+	var geocoding openMeteoGeocodingResponse
+	if err := s.get(ctx, s.geocodingURL+"/v1/search?"+query.Encode(), &geocoding); err != nil {
+		return nil, err
+	}
+	if len(geocoding.Results) == 0 {
+		return nil, fmt.Errorf("open-meteo: no location found for %s/%s", loc.City, loc.State)
+	}
+	match := geocoding.Results[0]
+	state := strings.ToLower(removeAccents(loc.State))
+	if name, ok := brazilianStates[strings.ToUpper(loc.State)]; ok && loc.country() == defaultCountry {
+		state = strings.ToLower(removeAccents(name))
+	}
+	for _, result := range geocoding.Results {
+		if strings.ToLower(removeAccents(result.Admin1)) == state {
+			match = result
+			break
+		}
+	}
+	observation.City, observation.Region, observation.Country = match.Name, match.Admin1, match.Country
+	return &coordinates{Lat: match.Latitude, Lon: match.Longitude}, nil
+}
+
+func (s *OpenMeteoSource) get(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.breaker.call(ctx, func() (*http.Response, error) {
+		return s.retry.do(ctx, weatherProviderOpenMeteo, func() (*http.Response, error) {
+			return s.httpClient.Do(req)
+		})
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("open-meteo error: %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
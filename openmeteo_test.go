@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// fakeOpenMeteo serves both the geocoding search and the forecast endpoint.
+// "Bom Jesus" exists in several states, so the test can check that the UF
+// picks the right result.
+func newFakeOpenMeteo(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/search", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("name") {
+		case "São Paulo":
+			fmt.Fprint(w, `{"results": [{"name": "São Paulo", "latitude": -23.5475, "longitude": -46.6361, "country": "Brasil", "admin1": "São Paulo"}]}`)
+		case "Bom Jesus":
+			fmt.Fprint(w, `{"results": [
+				{"name": "Bom Jesus", "latitude": -9.0744, "longitude": -44.3586, "country": "Brasil", "admin1": "Piauí"},
+				{"name": "Bom Jesus", "latitude": -28.6697, "longitude": -50.4297, "country": "Brasil", "admin1": "Rio Grande do Sul"}
+			]}`)
+		default:
+			fmt.Fprint(w, `{}`)
+		}
+	})
+	mux.HandleFunc("/v1/forecast", func(w http.ResponseWriter, r *http.Request) {
+		temperatures := map[string]float64{"-23.5475": 21.0, "-28.6697": 12.5, "-9.0744": 33.0, "-23.5600": 19.0}
+		temperature, ok := temperatures[r.URL.Query().Get("latitude")]
+		if !ok {
+			http.Error(w, `{"error": true}`, http.StatusBadRequest)
+			return
+		}
+		fmt.Fprintf(w, `{"current": {"time": 1700000000, "temperature_2m": %.1f, "is_day": 1, "weather_code": 2, "uv_index": 4.5, "precipitation_probability": 10}}`, temperature)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestConditionFromWMOCode(t *testing.T) {
+	tests := []struct {
+		code     int
+		expected string
+	}{
+		{0, "clear"},
+		{2, "partly_cloudy"},
+		{63, "rain"},
+		{95, "thunderstorm"},
+		{42, "unknown"},
+	}
+
+	for _, tt := range tests {
+		if got := conditionFromWMOCode(tt.code); got != tt.expected {
+			t.Errorf("Expected code %d to map to %q, got %q", tt.code, tt.expected, got)
+		}
+	}
+}
+
+func TestE2E_OpenMeteoProvider(t *testing.T) {
+	openMeteo := newFakeOpenMeteo(t)
+	env := newE2EEnv(t, func(cfg *Config) {
+		cfg.WeatherProvider = weatherProviderOpenMeteo
+		cfg.WeatherAPIKey = ""
+		cfg.OpenMeteoGeocodingURL = openMeteo.URL
+		cfg.OpenMeteoForecastURL = openMeteo.URL
+		cfg.CoordinateRadiusKm = 30
+	})
+	env.viaCEP.set(func(f *fakeViaCEP) {
+		f.addresses["95290000"] = ViaCEPResponse{CEP: "95290-000", Localidade: "Bom Jesus", UF: "RS"}
+		f.addresses["64900000"] = ViaCEPResponse{CEP: "64900-000", Localidade: "Bom Jesus", UF: "PI"}
+	})
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantTempC  float64
+	}{
+		{"Cidade geocodificada", "/weather/01310100", http.StatusOK, 21.0},
+		{"Homônimo no RS", "/weather/95290000", http.StatusOK, 12.5},
+		{"Homônimo no PI", "/weather/64900000", http.StatusOK, 33.0},
+		{"Coordenadas do cliente", "/weather/01310100?lat=-23.56&lon=-46.65", http.StatusOK, 19.0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := env.get(t, tt.path, nil)
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, resp.StatusCode, body)
+			}
+			var response TemperatureResponse
+			if err := json.Unmarshal(body, &response); err != nil {
+				t.Fatalf("Error parsing response: %v", err)
+			}
+			if response.TempC != tt.wantTempC {
+				t.Errorf("Expected temp_C %.1f, got %.1f", tt.wantTempC, response.TempC)
+			}
+			if response.Condition == nil || response.Condition.Code != "partly_cloudy" {
+				t.Errorf("Unexpected condition: %+v", response.Condition)
+			}
+		})
+	}
+}
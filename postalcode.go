@@ -0,0 +1,61 @@
+package main
+
+import "strings"
+
+const defaultCountry = "br"
+
+// countryNames maps ISO 3166-1 alpha-2 codes to the English names WeatherAPI
+// expects at the end of its q parameter.
+var countryNames = map[string]string{
+	"br": "Brazil",
+	"pt": "Portugal",
+	"ao": "Angola",
+	"mz": "Mozambique",
+	"cv": "Cape Verde",
+}
+
+// PostalCodeResolver serves ?country= lookups for one country: Provider
+// resolves the code once Valid accepts it and Normalize has cleaned it up.
+type PostalCodeResolver struct {
+	Provider  CEPProvider
+	Valid     func(code string) bool
+	Normalize func(code string) string
+}
+
+// RegisterCountry plugs in a resolver for a country's postal codes. Brazil is
+// always served by app.cep and cannot be replaced.
+func (app *App) RegisterCountry(country string, resolver PostalCodeResolver) {
+	if app.countries == nil {
+		app.countries = make(map[string]PostalCodeResolver)
+	}
+	app.countries[strings.ToLower(country)] = resolver
+}
+
+func (app *App) resolverFor(country string) (PostalCodeResolver, bool) {
+	if country == defaultCountry {
+		return PostalCodeResolver{Provider: app.cep, Valid: isValidCEP, Normalize: normalizeCEP}, true
+	}
+	resolver, ok := app.countries[country]
+	return resolver, ok
+}
+
+func requestCountry(raw string) string {
+	if country := strings.ToLower(strings.TrimSpace(raw)); country != "" {
+		return country
+	}
+	return defaultCountry
+}
+
+func (l weatherLocation) country() string {
+	if l.Country == "" {
+		return defaultCountry
+	}
+	return l.Country
+}
+
+func (l weatherLocation) countryName() string {
+	if name, ok := countryNames[l.country()]; ok {
+		return name
+	}
+	return strings.ToUpper(l.country())
+}
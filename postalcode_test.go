@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var portugalPostalCode = regexp.MustCompile(`^[0-9]{4}-?[0-9]{3}$`)
+
+func TestHandleWeatherByCEP_Country(t *testing.T) {
+	env := newE2EEnv(t, nil)
+	env.weatherAPI.set(func(f *fakeWeatherAPI) { f.temperatures["Lisboa,Lisboa,Portugal"] = 18.0 })
+	env.app.RegisterCountry("PT", PostalCodeResolver{
+		Provider:  &fakeCEPProvider{address: &Address{CEP: "1100148", City: "Lisboa", State: "Lisboa"}},
+		Valid:     portugalPostalCode.MatchString,
+		Normalize: func(code string) string { return strings.ReplaceAll(code, "-", "") },
+	})
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedTempC  float64
+	}{
+		{"Brasil continua o padrão", "/weather/01310100", http.StatusOK, 22.5},
+		{"Brasil explícito", "/weather/01310100?country=BR", http.StatusOK, 22.5},
+		{"Código postal português", "/weather/1100-148?country=pt", http.StatusOK, 18.0},
+		{"Formato do país é validado", "/weather/01310100?country=pt", http.StatusUnprocessableEntity, 0},
+		{"País sem resolvedor", "/weather/1100-148?country=ao", http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := env.get(t, tt.path, nil)
+			if resp.StatusCode != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, resp.StatusCode, body)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			var response TemperatureResponse
+			if err := json.Unmarshal(body, &response); err != nil {
+				t.Fatal(err)
+			}
+			if response.TempC != tt.expectedTempC {
+				t.Errorf("Expected temp_C %.1f, got %.1f", tt.expectedTempC, response.TempC)
+			}
+		})
+	}
+}
+
+func TestWeatherCacheKey_Country(t *testing.T) {
+	br := weatherCacheKey(&Address{City: "Lisboa", State: "PI"})
+	pt := weatherCacheKey(&Address{City: "Lisboa", State: "PI", Country: "pt"})
+	if br == pt {
+		t.Errorf("Expected distinct cache keys per country, got %q for both", br)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/pprof"
+)
+
+func pprofHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return mux
+}
+
+// pprofComponent serves the profiling endpoints on their own listener, so
+// they can be bound to localhost or an internal interface only.
+func pprofComponent(addr string) Component {
+	server := &http.Server{Addr: addr, Handler: pprofHandler()}
+	return Component{
+		Name: "pprof",
+		Start: func(ctx context.Context) error {
+			listener, err := net.Listen("tcp", addr)
+			if err != nil {
+				return err
+			}
+			go func() {
+				if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					slog.Error("pprof listener failed", "error", err)
+				}
+			}()
+			slog.Info("pprof listening", "addr", listener.Addr().String())
+			return nil
+		},
+		Stop: server.Shutdown,
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestPprofAdmin(t *testing.T) {
+	tests := []struct {
+		name           string
+		pprofAdmin     bool
+		path           string
+		token          string
+		expectedStatus int
+	}{
+		{"Índice com token", true, "/debug/pprof/", "admin-secret", http.StatusOK},
+		{"Perfil de heap com token", true, "/debug/pprof/heap?debug=1", "admin-secret", http.StatusOK},
+		{"Sem token", true, "/debug/pprof/", "", http.StatusUnauthorized},
+		{"Desligado por padrão", false, "/debug/pprof/", "admin-secret", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newE2EEnv(t, func(cfg *Config) {
+				cfg.AdminToken = "admin-secret"
+				cfg.PprofAdmin = tt.pprofAdmin
+			})
+			if status := env.do(t, "GET", tt.path, tt.token); status != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, status)
+			}
+		})
+	}
+}
+
+func TestPprofComponent(t *testing.T) {
+	component := pprofComponent("127.0.0.1:0")
+	if err := component.Start(context.Background()); err != nil {
+		t.Fatalf("Expected listener to start, got %v", err)
+	}
+	if err := component.Stop(context.Background()); err != nil {
+		t.Errorf("Expected clean shutdown, got %v", err)
+	}
+}
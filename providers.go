@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// CEPProvider resolves a CEP to an address. RetryAfter tells the handler how
+// long to back off when err says the provider is unavailable.
+type CEPProvider interface {
+	GetCEPInfo(ctx context.Context, cep string) (*Address, error)
+	RetryAfter(err error) time.Duration
+}
+
+// WeatherProvider returns the current observation for a municipality or a
+// point, plus the X-Cache status it was served with.
+type WeatherProvider interface {
+	LookupTemperature(ctx context.Context, address *Address) (*Observation, string, error)
+	LookupTemperatureAt(ctx context.Context, point coordinates) (*Observation, string, error)
+	RetryAfter(err error) time.Duration
+}
+
+func (s *CEPService) RetryAfter(err error) time.Duration {
+	if errors.Is(err, ErrCircuitOpen) {
+		return s.breaker.retryAfter()
+	}
+	return 0
+}
+
+func (s *WeatherService) RetryAfter(err error) time.Duration {
+	switch {
+	case errors.Is(err, ErrWeatherQuotaExceeded):
+		return s.quota.retryAfter()
+	case errors.Is(err, ErrCircuitOpen):
+		return s.breaker.retryAfter()
+	}
+	return 0
+}
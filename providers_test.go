@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type fakeCEPProvider struct {
+	address *Address
+	err     error
+}
+
+func (f *fakeCEPProvider) GetCEPInfo(ctx context.Context, cep string) (*Address, error) {
+	return f.address, f.err
+}
+
+func (f *fakeCEPProvider) RetryAfter(err error) time.Duration { return 7 * time.Second }
+
+type fakeWeatherProvider struct {
+	observation *Observation
+	err         error
+}
+
+func (f *fakeWeatherProvider) LookupTemperature(ctx context.Context, address *Address) (*Observation, string, error) {
+	return f.observation, cacheStatusHit, f.err
+}
+
+func (f *fakeWeatherProvider) LookupTemperatureAt(ctx context.Context, point coordinates) (*Observation, string, error) {
+	return f.observation, cacheStatusHit, f.err
+}
+
+func (f *fakeWeatherProvider) RetryAfter(err error) time.Duration { return 3 * time.Second }
+
+func TestHandleWeatherByCEP_Providers(t *testing.T) {
+	address := &Address{CEP: "01310100", City: "São Paulo", State: "SP"}
+	tests := []struct {
+		name           string
+		cep            *fakeCEPProvider
+		weather        *fakeWeatherProvider
+		wantStatus     int
+		wantRetryAfter string
+	}{
+		{"Provedores respondem", &fakeCEPProvider{address: address}, &fakeWeatherProvider{observation: &Observation{TempC: 20}}, http.StatusOK, ""},
+		{"Provedor de CEP indisponível", &fakeCEPProvider{err: ErrCircuitOpen}, &fakeWeatherProvider{}, http.StatusServiceUnavailable, "7"},
+		{"Cota de clima esgotada", &fakeCEPProvider{address: address}, &fakeWeatherProvider{err: ErrWeatherQuotaExceeded}, http.StatusServiceUnavailable, "3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			NewApp(tt.cep, tt.weather).setupRoutes().ServeHTTP(rr, httptest.NewRequest("GET", "/weather/01310100", nil))
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, rr.Code, rr.Body.String())
+			}
+			if got := rr.Header().Get("Retry-After"); got != tt.wantRetryAfter {
+				t.Errorf("Expected Retry-After %q, got %q", tt.wantRetryAfter, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// handleViaCEPProxy serves ViaCEP-shaped payloads to internal consumers. It
+// goes through CEPService so every lookup shares the same upstream selection
+// and error handling as the weather endpoint.
+func (app *App) handleViaCEPProxy(w http.ResponseWriter, r *http.Request) {
+	cep := mux.Vars(r)["cep"]
+	if !isValidCEP(cep) {
+		writeJSON(w, http.StatusUnprocessableEntity, ErrorResponse{Message: "invalid zipcode"})
+		return
+	}
+	cepInfo, err := app.cep.GetCEPInfo(r.Context(), normalizeCEP(cep))
+	if errors.Is(err, ErrCEPNotFound) {
+		writeJSON(w, http.StatusOK, map[string]bool{"erro": true})
+		return
+	}
+	if errors.Is(err, ErrCircuitOpen) {
+		writeRetryAfter(w, app.cep.RetryAfter(err))
+		writeJSON(w, http.StatusServiceUnavailable, ErrorResponse{Message: "cep provider unavailable"})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error proxying ViaCEP lookup", "client_tag", clientTagFromContext(r.Context()), "error", err)
+		writeJSON(w, http.StatusBadGateway, ErrorResponse{Message: "error querying viacep"})
+		return
+	}
+	writeJSON(w, http.StatusOK, viaCEPResponseFromAddress(cepInfo))
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandleViaCEPProxy(t *testing.T) {
+	mockClient := NewMockHTTPClient()
+	app := NewApp(NewCEPService(mockClient), NewWeatherService(mockClient, "test-api-key"))
+	app.viaCEPProxy = true
+	router := app.setupRoutes()
+
+	t.Run("CEP encontrado", func(t *testing.T) {
+		mockClient.AddResponse("https://viacep.com.br/ws/01310100/json/", 200, `{"cep": "01310-100", "localidade": "São Paulo", "uf": "SP", "ibge": "3550308"}`)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/proxy/viacep/01310-100", nil))
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", rr.Code)
+		}
+		var response ViaCEPResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Error parsing response: %v", err)
+		}
+		if response.IBGE != "3550308" {
+			t.Errorf("Expected ibge '3550308', got '%s'", response.IBGE)
+		}
+	})
+
+	t.Run("CEP não encontrado mantém o formato da ViaCEP", func(t *testing.T) {
+		mockClient.AddResponse("https://viacep.com.br/ws/99999999/json/", 200, `{"erro": true}`)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/proxy/viacep/99999999", nil))
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", rr.Code)
+		}
+		if body := rr.Body.String(); body != "{\"erro\":true}\n" {
+			t.Errorf("Unexpected body: %s", body)
+		}
+	})
+
+	t.Run("Falha na ViaCEP", func(t *testing.T) {
+		mockClient.AddError("https://viacep.com.br/ws/12345678/json/", errors.New("connection error"))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/proxy/viacep/12345678", nil))
+
+		if rr.Code != http.StatusBadGateway {
+			t.Errorf("Expected status 502, got %d", rr.Code)
+		}
+	})
+
+	t.Run("Modo proxy desabilitado", func(t *testing.T) {
+		app.viaCEPProxy = false
+		rr := httptest.NewRecorder()
+		app.setupRoutes().ServeHTTP(rr, httptest.NewRequest("GET", "/proxy/viacep/01310100", nil))
+
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", rr.Code)
+		}
+	})
+}
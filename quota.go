@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const weatherAPIQuotaExceededCode = 2007
+
+var ErrWeatherQuotaExceeded = errors.New("weather API quota exceeded")
+
+type weatherAPIErrorResponse struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// weatherQuota remembers that WeatherAPI rejected us for exhausting the
+// monthly quota, so we stop calling it until the quota window resets.
+type weatherQuota struct {
+	mu             sync.Mutex
+	exhaustedUntil time.Time
+	exceededTotal  int
+	now            func() time.Time
+	// exhaustions, when set, exports every exhaustion for alerting.
+	exhaustions prometheus.Counter
+}
+
+func newWeatherQuota() *weatherQuota {
+	return &weatherQuota{now: time.Now}
+}
+
+func isQuotaExceededBody(body io.Reader) bool {
+	var errResp weatherAPIErrorResponse
+	if err := json.NewDecoder(body).Decode(&errResp); err != nil {
+		return false
+	}
+	return errResp.Error.Code == weatherAPIQuotaExceededCode
+}
+
+// nextQuotaReset returns the start of the next calendar month in UTC, which is
+// when WeatherAPI renews monthly call quotas.
+func nextQuotaReset(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func (q *weatherQuota) markExhausted() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.exceededTotal++
+	q.exhaustedUntil = nextQuotaReset(q.now())
+	if q.exhaustions != nil {
+		q.exhaustions.Inc()
+	}
+	slog.Error("ALERT: WeatherAPI quota exceeded, suspending calls", "until", q.exhaustedUntil.Format(time.RFC3339), "occurrences", q.exceededTotal)
+}
+
+// retryAfter reports how long callers must wait before WeatherAPI may be
+// called again; zero means the quota is available.
+func (q *weatherQuota) retryAfter() time.Duration {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.exhaustedUntil.IsZero() {
+		return 0
+	}
+	remaining := q.exhaustedUntil.Sub(q.now())
+	if remaining <= 0 {
+		q.exhaustedUntil = time.Time{}
+		return 0
+	}
+	return remaining
+}
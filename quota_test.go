@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNextQuotaReset(t *testing.T) {
+	tests := []struct {
+		name     string
+		now      time.Time
+		expected time.Time
+	}{
+		{"Meio do mês", time.Date(2024, 5, 17, 13, 0, 0, 0, time.UTC), time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{"Virada do ano", time.Date(2024, 12, 31, 23, 59, 0, 0, time.UTC), time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := nextQuotaReset(tt.now); !result.Equal(tt.expected) {
+				t.Errorf("nextQuotaReset(%s) = %s, expected %s", tt.now, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWeatherService_QuotaExceeded(t *testing.T) {
+	weatherURL := "https://api.weatherapi.com/v1/forecast.json?key=test-api-key&q=Sao+Paulo%2CSP%2CBrazil&days=1&aqi=no&alerts=no"
+	quotaBody := `{"error": {"code": 2007, "message": "API key has exceeded calls per month quota."}}`
+	now := time.Date(2024, 5, 17, 13, 0, 0, 0, time.UTC)
+
+	t.Run("Suspende chamadas até a renovação da cota", func(t *testing.T) {
+		mockClient := NewMockHTTPClient()
+		service := NewWeatherService(mockClient, "test-api-key")
+		service.quota.now = func() time.Time { return now }
+		mockClient.AddResponse(weatherURL, 403, quotaBody)
+
+		if _, err := service.GetTemperature(context.Background(), "São Paulo", "SP"); !errors.Is(err, ErrWeatherQuotaExceeded) {
+			t.Fatalf("Expected ErrWeatherQuotaExceeded, got %v", err)
+		}
+
+		mockClient.AddResponse(weatherURL, 200, `{"current": {"temp_c": 20.0}}`)
+		if _, err := service.GetTemperature(context.Background(), "São Paulo", "SP"); !errors.Is(err, ErrWeatherQuotaExceeded) {
+			t.Errorf("Expected calls to stay suspended, got %v", err)
+		}
+
+		service.quota.now = func() time.Time { return time.Date(2024, 6, 1, 0, 0, 1, 0, time.UTC) }
+		result, err := service.GetTemperature(context.Background(), "São Paulo", "SP")
+		if err != nil {
+			t.Fatalf("Expected calls to resume after reset, got %v", err)
+		}
+		if result.TempC != 20.0 {
+			t.Errorf("Expected temperature 20.0°C, got %.1f°C", result.TempC)
+		}
+	})
+
+	t.Run("Outros erros 403 não suspendem chamadas", func(t *testing.T) {
+		mockClient := NewMockHTTPClient()
+		service := NewWeatherService(mockClient, "test-api-key")
+		mockClient.AddResponse(weatherURL, 403, `{"error": {"code": 2008, "message": "API key has been disabled."}}`)
+
+		_, err := service.GetTemperature(context.Background(), "São Paulo", "SP")
+		if err == nil || errors.Is(err, ErrWeatherQuotaExceeded) {
+			t.Errorf("Expected generic weather API error, got %v", err)
+		}
+		if service.quota.retryAfter() != 0 {
+			t.Error("Expected quota to remain available")
+		}
+	})
+
+	t.Run("Handler retorna 503 com Retry-After", func(t *testing.T) {
+		mockClient := NewMockHTTPClient()
+		weatherService := NewWeatherService(mockClient, "test-api-key")
+		weatherService.quota.now = func() time.Time { return time.Date(2024, 5, 31, 23, 0, 0, 0, time.UTC) }
+		app := NewApp(NewCEPService(mockClient), weatherService)
+		mockClient.AddResponse("https://viacep.com.br/ws/01310100/json/", 200, `{"localidade": "São Paulo", "uf": "SP"}`)
+		mockClient.AddResponse(weatherURL, 403, quotaBody)
+
+		rr := httptest.NewRecorder()
+		app.setupRoutes().ServeHTTP(rr, httptest.NewRequest("GET", "/weather/01310100", nil))
+
+		if rr.Code != http.StatusServiceUnavailable {
+			t.Errorf("Expected status 503, got %d", rr.Code)
+		}
+		if retryAfter := rr.Header().Get("Retry-After"); retryAfter != "3600" {
+			t.Errorf("Expected Retry-After 3600, got %s", retryAfter)
+		}
+	})
+}
+
+func TestE2E_QuotaExhaustedFallbacks(t *testing.T) {
+	t.Run("Serve a última observação em cache", func(t *testing.T) {
+		env := newE2EEnv(t, func(cfg *Config) { cfg.WeatherCacheTTL = time.Millisecond })
+		env.get(t, "/weather/01310100", nil)
+		time.Sleep(5 * time.Millisecond)
+		env.weatherAPI.set(func(f *fakeWeatherAPI) { f.quotaExceeded = true })
+
+		resp, body := env.get(t, "/weather/01310100", nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", resp.StatusCode, body)
+		}
+		if resp.Header.Get("X-Cache") != cacheStatusStale {
+			t.Errorf("Expected X-Cache STALE, got %s", resp.Header.Get("X-Cache"))
+		}
+	})
+
+	t.Run("Consulta o Open-Meteo sem cache", func(t *testing.T) {
+		openMeteo := newFakeOpenMeteo(t)
+		env := newE2EEnv(t, func(cfg *Config) {
+			cfg.WeatherQuotaFallback = weatherProviderOpenMeteo
+			cfg.OpenMeteoGeocodingURL = openMeteo.URL
+			cfg.OpenMeteoForecastURL = openMeteo.URL
+		})
+		env.weatherAPI.set(func(f *fakeWeatherAPI) { f.quotaExceeded = true })
+
+		resp, body := env.get(t, "/weather/01310100", nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", resp.StatusCode, body)
+		}
+		var response TemperatureResponse
+		if err := json.Unmarshal(body, &response); err != nil {
+			t.Fatalf("Error parsing response: %v", err)
+		}
+		if response.TempC != 21.0 {
+			t.Errorf("Expected Open-Meteo's 21.0°C, got %.1f°C", response.TempC)
+		}
+	})
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	env := newE2EEnv(t, nil)
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    string
+	}{
+		{"ID do cliente é repassado", map[string]string{requestIDHeader: "checkout-42"}, "checkout-42"},
+		{"ID gerado quando ausente", nil, ""},
+		{"ID inválido é substituído", map[string]string{requestIDHeader: "bad id\twith spaces"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := env.get(t, "/weather/99999999", tt.headers)
+			if resp.StatusCode != http.StatusNotFound {
+				t.Fatalf("Expected status 404, got %d", resp.StatusCode)
+			}
+			id := resp.Header.Get(requestIDHeader)
+			if tt.want != "" && id != tt.want {
+				t.Errorf("Expected request ID %q, got %q", tt.want, id)
+			}
+			if !requestIDPattern.MatchString(id) {
+				t.Errorf("Expected a valid request ID, got %q", id)
+			}
+
+			var errResp ErrorResponse
+			if err := json.Unmarshal(body, &errResp); err != nil {
+				t.Fatalf("Expected JSON error body, got %q", body)
+			}
+			if errResp.RequestID != id {
+				t.Errorf("Expected request_id %q in body, got %q", id, errResp.RequestID)
+			}
+		})
+	}
+}
+
+func TestContextHandler_AddsRequestID(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := newLogger(&out, "info", "json")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	ctx := context.WithValue(context.Background(), requestIDContextKey, "checkout-42")
+	logger.With("cep", "01310100").InfoContext(ctx, "Weather lookup served")
+
+	var entry map[string]any
+	if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+		t.Fatalf("Expected a JSON line, got %q", out.String())
+	}
+	if entry["request_id"] != "checkout-42" || entry["cep"] != "01310100" {
+		t.Errorf("Expected request_id and cep in entry, got %v", entry)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RetryPolicy retries upstream calls that failed at the network level or
+// with a 5xx, waiting Backoff, 2*Backoff, ... (capped at MaxBackoff) between
+// attempts. Other statuses are answers, not blips, and are returned as is.
+type RetryPolicy struct {
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+	// retries, when set, counts every retry by provider.
+	retries *prometheus.CounterVec
+}
+
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	wait := p.Backoff << (attempt - 1)
+	if p.MaxBackoff > 0 && (wait > p.MaxBackoff || wait <= 0) {
+		wait = p.MaxBackoff
+	}
+	return wait
+}
+
+func (p RetryPolicy) do(ctx context.Context, upstream string, send func() (*http.Response, error)) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := send()
+		retryable := (err != nil && ctx.Err() == nil) || (err == nil && resp.StatusCode >= http.StatusInternalServerError)
+		if !retryable || attempt >= p.MaxAttempts {
+			return resp, err
+		}
+		reason := "network error"
+		if err == nil {
+			reason = http.StatusText(resp.StatusCode)
+			resp.Body.Close()
+		}
+		wait := p.backoff(attempt)
+		if p.retries != nil {
+			p.retries.WithLabelValues(upstream).Inc()
+		}
+		slog.WarnContext(ctx, "Retrying upstream call", "upstream", upstream, "reason", reason, "wait", wait, "attempt", attempt+1, "max_attempts", p.MaxAttempts)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
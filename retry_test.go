@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// scriptedHTTPClient answers each call with the next scripted status; 0
+// stands for a network error. The last entry repeats.
+type scriptedHTTPClient struct {
+	statuses []int
+	body     string
+	calls    int32
+}
+
+func (c *scriptedHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	call := int(atomic.AddInt32(&c.calls, 1)) - 1
+	status := c.statuses[min(call, len(c.statuses)-1)]
+	if status == 0 {
+		return nil, errors.New("connection reset by peer")
+	}
+	return &http.Response{
+		StatusCode: status,
+		Body:       io.NopCloser(strings.NewReader(c.body)),
+		Header:     make(http.Header),
+	}, nil
+}
+
+func TestRetryPolicy(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}
+	tests := []struct {
+		name           string
+		statuses       []int
+		expectedCalls  int32
+		expectedStatus int
+		expectError    bool
+	}{
+		{"Sucesso sem retentativa", []int{200}, 1, 200, false},
+		{"Erro 5xx transitório", []int{502, 200}, 2, 200, false},
+		{"Erro de rede transitório", []int{0, 0, 200}, 3, 200, false},
+		{"Erro 4xx não é repetido", []int{400, 200}, 1, 400, false},
+		{"Tentativas esgotadas", []int{503}, 3, 503, false},
+		{"Erro de rede persistente", []int{0}, 3, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &scriptedHTTPClient{statuses: tt.statuses}
+			req, _ := http.NewRequest("GET", "https://upstream.local", nil)
+			resp, err := policy.do(context.Background(), "test", func() (*http.Response, error) {
+				return client.Do(req)
+			})
+			if client.calls != tt.expectedCalls {
+				t.Errorf("Expected %d calls, got %d", tt.expectedCalls, client.calls)
+			}
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected an error")
+				}
+				return
+			}
+			if err != nil || resp.StatusCode != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %v (err=%v)", tt.expectedStatus, resp, err)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{Backoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	for attempt, expected := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second} {
+		if wait := policy.backoff(attempt + 1); wait != expected {
+			t.Errorf("Attempt %d: expected backoff %s, got %s", attempt+1, expected, wait)
+		}
+	}
+}
+
+func TestRetryPolicy_StopsWhenContextEnds(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 5, Backoff: time.Second}
+	client := &scriptedHTTPClient{statuses: []int{503}}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	req, _ := http.NewRequest("GET", "https://upstream.local", nil)
+	if _, err := policy.do(ctx, "test", func() (*http.Response, error) { return client.Do(req) }); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if client.calls != 1 {
+		t.Errorf("Expected 1 call before the deadline, got %d", client.calls)
+	}
+}
+
+func TestWeatherService_RetriesTransientErrors(t *testing.T) {
+	client := &scriptedHTTPClient{statuses: []int{503, 200}, body: `{"current": {"temp_c": 25.0}}`}
+	service := NewWeatherService(client, "test-api-key")
+	service.retry = RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond}
+
+	result, err := service.GetTemperature(context.Background(), "São Paulo", "SP")
+	if err != nil {
+		t.Fatalf("Expected the retry to succeed, got %v", err)
+	}
+	if result.TempC != 25.0 {
+		t.Errorf("Expected 25.0°C, got %.1f", result.TempC)
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	riskHorizon = 48 * time.Hour
+	// Wind below riskWindCalmKph adds no risk; at riskWindSevereKph it is
+	// the maximum.
+	riskWindCalmKph   = 20.0
+	riskWindSevereKph = 70.0
+)
+
+// RiskWeights sets how much each factor counts in a risk profile. Weights
+// are relative: the score is their weighted mean.
+type RiskWeights struct {
+	Precipitation float64 `json:"precipitation"`
+	Wind          float64 `json:"wind"`
+	Alerts        float64 `json:"alerts"`
+}
+
+var defaultRiskProfiles = map[string]RiskWeights{
+	"outdoor_event": {Precipitation: 0.5, Wind: 0.3, Alerts: 0.2},
+	// Schools close for flooding and civil defense alerts, not for wind.
+	"school": {Precipitation: 0.4, Wind: 0.1, Alerts: 0.5},
+}
+
+type RiskFactor struct {
+	Name         string     `json:"name"`
+	Score        int        `json:"score"`
+	Weight       float64    `json:"weight"`
+	Contribution float64    `json:"contribution"`
+	PeakAt       *time.Time `json:"peak_at,omitempty"`
+}
+
+type RiskResponse struct {
+	CEP     string         `json:"cep"`
+	Type    string         `json:"type"`
+	Score   int            `json:"score"`
+	Level   string         `json:"level"`
+	From    time.Time      `json:"from"`
+	Until   time.Time      `json:"until"`
+	Factors []RiskFactor   `json:"factors"`
+	Alerts  []WeatherAlert `json:"alerts,omitempty"`
+}
+
+// precipitationRisk weighs the chance of rain by its volume: a likely
+// drizzle scores half of a likely downpour of 10 mm or more.
+func precipitationRisk(hour HourlyForecast) float64 {
+	return float64(hour.ChanceOfRain) * math.Min(1, 0.5+hour.PrecipMM/20)
+}
+
+func windRisk(hour HourlyForecast) float64 {
+	return math.Max(0, math.Min(100, (hour.WindKph-riskWindCalmKph)/(riskWindSevereKph-riskWindCalmKph)*100))
+}
+
+func alertRisk(alert WeatherAlert) float64 {
+	switch strings.ToLower(alert.Severity) {
+	case "extreme":
+		return 100
+	case "severe":
+		return 80
+	case "moderate":
+		return 50
+	default:
+		return 30
+	}
+}
+
+func riskLevel(score int) string {
+	switch {
+	case score < 30:
+		return "low"
+	case score < 60:
+		return "moderate"
+	default:
+		return "high"
+	}
+}
+
+// assessRisk scores the next riskHorizon hours. Hourly factors take their
+// worst hour, since one storm is enough to cancel an event.
+func assessRisk(forecast *Forecast, weights RiskWeights, now time.Time) RiskResponse {
+	response := RiskResponse{From: now, Until: now.Add(riskHorizon), Alerts: forecast.Alerts}
+	peak := func(name string, weight float64, risk func(HourlyForecast) float64) RiskFactor {
+		factor := RiskFactor{Name: name, Weight: weight}
+		worst := -1.0
+		for _, hour := range forecast.Hours {
+			if !hour.Time.Add(time.Hour).After(now) || !hour.Time.Before(response.Until) {
+				continue
+			}
+			if r := risk(hour); r > worst {
+				worst = r
+				factor.Score = int(math.Round(r))
+				at := hour.Time
+				factor.PeakAt = &at
+			}
+		}
+		if factor.Score == 0 {
+			factor.PeakAt = nil
+		}
+		return factor
+	}
+	alerts := RiskFactor{Name: "alerts", Weight: weights.Alerts}
+	for _, alert := range forecast.Alerts {
+		alerts.Score = max(alerts.Score, int(alertRisk(alert)))
+	}
+	response.Factors = []RiskFactor{
+		peak("precipitation", weights.Precipitation, precipitationRisk),
+		peak("wind", weights.Wind, windRisk),
+		alerts,
+	}
+
+	total := weights.Precipitation + weights.Wind + weights.Alerts
+	var score float64
+	for i := range response.Factors {
+		f := &response.Factors[i]
+		f.Contribution = roundTo(float64(f.Score)*f.Weight/total, 1)
+		score += float64(f.Score) * f.Weight / total
+	}
+	response.Score = int(math.Round(score))
+	response.Level = riskLevel(response.Score)
+	return response
+}
+
+// loadRiskProfiles reads a JSON object of profile name to weights. Profiles
+// in the file are added to the built-in ones, replacing those with the same
+// name.
+func loadRiskProfiles(path string) (map[string]RiskWeights, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var custom map[string]RiskWeights
+	if err := json.Unmarshal(data, &custom); err != nil {
+		return nil, fmt.Errorf("invalid risk profiles: %w", err)
+	}
+	profiles := make(map[string]RiskWeights, len(defaultRiskProfiles)+len(custom))
+	for name, weights := range defaultRiskProfiles {
+		profiles[name] = weights
+	}
+	for name, weights := range custom {
+		if weights.Precipitation < 0 || weights.Wind < 0 || weights.Alerts < 0 {
+			return nil, fmt.Errorf("risk profile %q has a negative weight", name)
+		}
+		if weights.Precipitation+weights.Wind+weights.Alerts == 0 {
+			return nil, fmt.Errorf("risk profile %q has no weights", name)
+		}
+		profiles[name] = weights
+	}
+	return profiles, nil
+}
+
+func (app *App) handleRisk(w http.ResponseWriter, r *http.Request) {
+	riskType := r.URL.Query().Get("type")
+	if riskType == "" {
+		riskType = "outdoor_event"
+	}
+	weights, ok := app.riskProfiles[riskType]
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Message: "unknown risk type"})
+		return
+	}
+	cep, forecast, ok := app.forecastForRequest(w, r)
+	if !ok {
+		return
+	}
+	response := assessRisk(forecast, weights, time.Now())
+	response.CEP = cep
+	response.Type = riskType
+	writeJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAssessRisk(t *testing.T) {
+	now := time.Date(2030, 1, 2, 10, 0, 0, 0, time.UTC)
+	at := func(h int) time.Time { return now.Add(time.Duration(h) * time.Hour) }
+	hours := []HourlyForecast{
+		{Time: at(-3), ChanceOfRain: 100, PrecipMM: 30, WindKph: 90},
+		{Time: at(2), ChanceOfRain: 40, PrecipMM: 1},
+		{Time: at(20), ChanceOfRain: 90, PrecipMM: 12, WindKph: 45},
+		{Time: at(50), ChanceOfRain: 100, PrecipMM: 30, WindKph: 90},
+	}
+
+	tests := []struct {
+		name     string
+		forecast *Forecast
+		weights  RiskWeights
+		score    int
+		level    string
+		factors  string
+	}{
+		{"Sem previsão de risco", &Forecast{Hours: []HourlyForecast{{Time: at(1)}}}, defaultRiskProfiles["outdoor_event"], 0, "low", "precipitation=0 wind=0 alerts=0"},
+		{"Chuva e vento no horizonte", &Forecast{Hours: hours}, defaultRiskProfiles["outdoor_event"], 60, "high", "precipitation=90 wind=50 alerts=0"},
+		{"Alerta pesa mais para escolas", &Forecast{Hours: hours, Alerts: []WeatherAlert{{Event: "Tempestade", Severity: "Severe"}}}, defaultRiskProfiles["school"], 81, "high", "precipitation=90 wind=50 alerts=80"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := assessRisk(tt.forecast, tt.weights, now)
+			var factors []string
+			for _, f := range got.Factors {
+				factors = append(factors, fmt.Sprintf("%s=%d", f.Name, f.Score))
+			}
+			if got.Score != tt.score || got.Level != tt.level || strings.Join(factors, " ") != tt.factors {
+				t.Errorf("Expected %d %s [%s], got %d %s %v", tt.score, tt.level, tt.factors, got.Score, got.Level, factors)
+			}
+		})
+	}
+
+	t.Run("Pico identifica a hora", func(t *testing.T) {
+		got := assessRisk(&Forecast{Hours: hours}, defaultRiskProfiles["outdoor_event"], now)
+		if got.Factors[0].PeakAt == nil || !got.Factors[0].PeakAt.Equal(at(20)) {
+			t.Errorf("Expected precipitation peak at %v, got %v", at(20), got.Factors[0].PeakAt)
+		}
+		if got.Factors[2].PeakAt != nil {
+			t.Errorf("Expected no peak for alerts, got %v", got.Factors[2].PeakAt)
+		}
+	})
+}
+
+func TestLoadRiskProfiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"Perfil adicionado", `{"construction": {"precipitation": 0.3, "wind": 0.7}}`, ""},
+		{"Peso negativo", `{"construction": {"wind": -1}}`, `risk profile "construction" has a negative weight`},
+		{"Sem pesos", `{"construction": {}}`, `risk profile "construction" has no weights`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "profiles.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			profiles, err := loadRiskProfiles(path)
+			if tt.wantErr == "" {
+				if err != nil || len(profiles) != len(defaultRiskProfiles)+1 {
+					t.Errorf("Expected built-in profiles plus one, got %v (%v)", profiles, err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Expected error %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestHandleRisk(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Hour)
+	weatherResponse := fmt.Sprintf(`{
+		"location": {"name": "São Paulo"},
+		"forecast": {"forecastday": [{"hour": [{"time_epoch": %d, "chance_of_rain": 80, "precip_mm": 10, "wind_kph": 20}]}]},
+		"alerts": {"alert": [{"headline": "Alerta de tempestade", "event": "Tempestade", "severity": "Moderate"}]}
+	}`, now.Add(time.Hour).Unix())
+
+	mockClient := NewMockHTTPClient()
+	app := NewApp(NewCEPService(mockClient), NewWeatherService(mockClient, "test-api-key"))
+	handler := app.setupRoutes()
+
+	tests := []struct {
+		name     string
+		path     string
+		expected int
+	}{
+		{"Tipo desconhecido", "/risk/01310100?type=wedding", http.StatusBadRequest},
+		{"Evento ao ar livre", "/risk/01310100?type=outdoor_event", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient.AddResponse("https://viacep.com.br/ws/01310100/json/", 200, `{"cep": "01310-100", "localidade": "São Paulo", "uf": "SP"}`)
+			mockClient.AddResponse("https://api.weatherapi.com/v1/forecast.json?key=test-api-key&q=Sao+Paulo%2CSP%2CBrazil&days=3&aqi=no&alerts=yes", 200, weatherResponse)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest("GET", tt.path, nil))
+			if rec.Code != tt.expected {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expected, rec.Code, rec.Body.String())
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+			var response RiskResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Error parsing response: %v", err)
+			}
+			if response.Score != 50 || response.Level != "moderate" || len(response.Alerts) != 1 || len(response.Factors) != 3 {
+				t.Errorf("Unexpected response %+v", response)
+			}
+		})
+	}
+}
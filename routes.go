@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const validateTimeout = time.Minute
+
+// routeSpec is one entry of the route table. Behaviour that differs per
+// endpoint is declared here and applied by setupRoutes, instead of being
+// wired by hand around each handler.
+type routeSpec struct {
+	Method  string
+	Path    string
+	Handler http.Handler
+	// Prefix matches every path under Path.
+	Prefix bool
+	// Admin requires the ADMIN_TOKEN bearer token.
+	Admin bool
+	// Tracked routes feed abuse detection and the latency SLO.
+	Tracked bool
+	// CDNCache emits the CACHE_MAX_AGE/CACHE_S_MAXAGE headers.
+	CDNCache bool
+	// Timeout sets a deadline on the request context; zero means none.
+	Timeout time.Duration
+	// Internal routes move to the internal listener when INTERNAL_ADDR is
+	// set.
+	Internal bool
+}
+
+func (app *App) routes() []routeSpec {
+	routes := []routeSpec{
+		{Method: http.MethodGet, Path: "/weather/{cep}", Handler: http.HandlerFunc(app.handleWeatherByCEP), Tracked: true, CDNCache: true},
+		{Method: http.MethodGet, Path: "/delivery-window/{cep}", Handler: http.HandlerFunc(app.handleDeliveryWindow), CDNCache: true},
+		{Method: http.MethodGet, Path: "/risk/{cep}", Handler: http.HandlerFunc(app.handleRisk), CDNCache: true},
+		{Method: http.MethodGet, Path: "/energy/{cep}", Handler: http.HandlerFunc(app.handleEnergy), CDNCache: true},
+		{Method: http.MethodGet, Path: "/suggestion/{cep}", Handler: http.HandlerFunc(app.handleSuggestion), CDNCache: true},
+		{Method: http.MethodGet, Path: "/health/deep", Handler: http.HandlerFunc(app.handleDeepHealth), Internal: true},
+		{Method: http.MethodGet, Path: "/status", Handler: http.HandlerFunc(app.handleStatus)},
+		{Method: http.MethodPost, Path: "/address/validate", Handler: http.HandlerFunc(app.handleAddressValidate), Timeout: validateTimeout},
+		{Method: http.MethodGet, Path: "/metrics", Handler: app.metrics.handler(), Internal: true},
+	}
+	if app.viaCEPProxy {
+		routes = append(routes, routeSpec{Method: http.MethodGet, Path: "/proxy/viacep/{cep}", Handler: http.HandlerFunc(app.handleViaCEPProxy), CDNCache: true})
+	}
+	if app.adminToken != "" {
+		routes = append(routes, app.adminRoutes()...)
+	}
+	return routes
+}
+
+func (app *App) wrapRoute(spec routeSpec) http.Handler {
+	handler := spec.Handler
+	if spec.Timeout > 0 {
+		handler = withTimeout(handler, spec.Timeout)
+	}
+	if spec.Tracked && app.abuse != nil {
+		handler = app.abuse.middleware(handler)
+	}
+	if spec.Tracked && app.slo != nil {
+		handler = app.slo.middleware(handler)
+	}
+	if spec.CDNCache {
+		handler = app.cacheHeadersMiddleware(handler)
+	}
+	if spec.Admin {
+		handler = app.adminAuthMiddleware(handler)
+	}
+	return handler
+}
+
+func withTimeout(next http.Handler, timeout time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// setupRoutes serves the public API, plus the internal routes unless they
+// have a listener of their own.
+func (app *App) setupRoutes() http.Handler {
+	return app.router(func(spec routeSpec) bool { return !spec.Internal || app.internalAddr == "" })
+}
+
+func (app *App) setupInternalRoutes() http.Handler {
+	return app.router(func(spec routeSpec) bool { return spec.Internal })
+}
+
+func (app *App) router(include func(routeSpec) bool) http.Handler {
+	r := mux.NewRouter()
+	r.Use(tracingMiddleware)
+	r.Use(app.metrics.middleware(app.metricsClientTag))
+	r.Use(app.clientTagMiddleware)
+	for _, spec := range app.routes() {
+		if !include(spec) {
+			continue
+		}
+		var route *mux.Route
+		if spec.Prefix {
+			route = r.PathPrefix(spec.Path)
+		} else {
+			route = r.Path(spec.Path)
+		}
+		route.Methods(spec.Method).Handler(app.wrapRoute(spec))
+	}
+	// Wrapping the router rather than using r.Use also covers requests
+	// that match no route.
+	var handler http.Handler = recoverMiddleware(r)
+	if app.accessLog != nil {
+		handler = app.accessLog.middleware(handler)
+	}
+	return requestIDMiddleware(handler)
+}
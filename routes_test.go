@@ -0,0 +1,130 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRoutes_Table(t *testing.T) {
+	app := NewApp(nil, nil)
+	app.adminToken = "admin-secret"
+	app.viaCEPProxy = true
+
+	byPath := make(map[string]routeSpec)
+	for _, spec := range app.routes() {
+		byPath[spec.Method+" "+spec.Path] = spec
+	}
+	tests := []struct {
+		name     string
+		route    string
+		admin    bool
+		tracked  bool
+		cdnCache bool
+	}{
+		{"Clima rastreado e cacheável", "GET /weather/{cep}", false, true, true},
+		{"Proxy cacheável", "GET /proxy/viacep/{cep}", false, false, true},
+		{"Métricas sem cache", "GET /metrics", false, false, false},
+		{"Admin protegido", "DELETE /admin/cache", true, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, ok := byPath[tt.route]
+			if !ok {
+				t.Fatalf("Expected route %s in table", tt.route)
+			}
+			if spec.Admin != tt.admin || spec.Tracked != tt.tracked || spec.CDNCache != tt.cdnCache {
+				t.Errorf("Unexpected config for %s: %+v", tt.route, spec)
+			}
+		})
+	}
+
+	t.Run("Admin ausente sem token", func(t *testing.T) {
+		for _, spec := range NewApp(nil, nil).routes() {
+			if spec.Admin {
+				t.Errorf("Expected no admin routes, got %s %s", spec.Method, spec.Path)
+			}
+		}
+	})
+}
+
+func TestWrapRoute(t *testing.T) {
+	app := &App{adminToken: "admin-secret", cdnPolicy: CDNCachePolicy{MaxAge: time.Minute}}
+	var deadline bool
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, deadline = r.Context().Deadline()
+		writeJSON(w, http.StatusOK, TemperatureResponse{TempC: 25})
+	})
+
+	tests := []struct {
+		name         string
+		spec         routeSpec
+		token        string
+		status       int
+		cacheControl string
+		deadline     bool
+	}{
+		{"Rota simples", routeSpec{}, "", http.StatusOK, "", false},
+		{"Cache de CDN", routeSpec{CDNCache: true}, "", http.StatusOK, "public, max-age=60, s-maxage=60", false},
+		{"Admin sem token", routeSpec{Admin: true}, "", http.StatusUnauthorized, "", false},
+		{"Admin com token", routeSpec{Admin: true}, "admin-secret", http.StatusOK, "", false},
+		{"Timeout no contexto", routeSpec{Timeout: time.Second}, "", http.StatusOK, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deadline = false
+			tt.spec.Handler = handler
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			app.wrapRoute(tt.spec).ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, rec.Code)
+			}
+			if got := rec.Header().Get("Cache-Control"); got != tt.cacheControl {
+				t.Errorf("Expected Cache-Control %q, got %q", tt.cacheControl, got)
+			}
+			if deadline != tt.deadline {
+				t.Errorf("Expected deadline %v, got %v", tt.deadline, deadline)
+			}
+		})
+	}
+}
+
+func TestSetupRoutes_InternalSplit(t *testing.T) {
+	tests := []struct {
+		name         string
+		internalAddr string
+		public       int
+		internal     int
+	}{
+		{"Listener único", "", http.StatusOK, http.StatusOK},
+		{"Listener interno separado", "127.0.0.1:9090", http.StatusNotFound, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := NewApp(nil, nil)
+			app.internalAddr = tt.internalAddr
+
+			rec := httptest.NewRecorder()
+			app.setupRoutes().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+			if rec.Code != tt.public {
+				t.Errorf("Expected public /metrics status %d, got %d", tt.public, rec.Code)
+			}
+			rec = httptest.NewRecorder()
+			app.setupInternalRoutes().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+			if rec.Code != tt.internal {
+				t.Errorf("Expected internal /metrics status %d, got %d", tt.internal, rec.Code)
+			}
+			rec = httptest.NewRecorder()
+			app.setupInternalRoutes().ServeHTTP(rec, httptest.NewRequest("GET", "/health", nil))
+			if rec.Code != http.StatusNotFound {
+				t.Errorf("Expected /health missing from internal listener, got %d", rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+const sentryFlushTimeout = 2 * time.Second
+
+// sentryComponent enables error reporting when SENTRY_DSN is set. The SDK
+// reads SENTRY_ENVIRONMENT and SENTRY_RELEASE from the environment itself.
+func sentryComponent(cfg Config) Component {
+	return Component{
+		Name: "sentry",
+		Start: func(ctx context.Context) error {
+			if cfg.SentryDSN == "" {
+				return nil
+			}
+			if err := sentry.Init(sentry.ClientOptions{Dsn: cfg.SentryDSN}); err != nil {
+				return err
+			}
+			slog.Info("Sentry error reporting enabled")
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			sentry.Flush(sentryFlushTimeout)
+			return nil
+		},
+	}
+}
+
+// captureUpstreamError reports a failed upstream lookup, tagged so it can be
+// matched to the request's log lines. The error is redacted first: a
+// transport error quotes the request URL, WeatherAPI key included. It is a
+// no-op without a DSN.
+func captureUpstreamError(ctx context.Context, err error, provider, cep, city string) {
+	hub := sentry.CurrentHub()
+	if hub.Client() == nil {
+		return
+	}
+	hub.WithScope(func(scope *sentry.Scope) {
+		scope.SetTag("provider", provider)
+		scope.SetTag("cep", cep)
+		if city != "" {
+			scope.SetTag("city", city)
+		}
+		if id := requestIDFromContext(ctx); id != "" {
+			scope.SetTag("request_id", id)
+		}
+		hub.CaptureException(redactURLError(err))
+	})
+}
+
+// recoverMiddleware turns a handler panic into a 500, logging the stack and
+// reporting it to Sentry instead of letting net/http drop the connection.
+func recoverMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+			slog.ErrorContext(r.Context(), "Handler panic", "panic", fmt.Sprint(recovered), "path", r.URL.Path, "stack", string(debug.Stack()))
+			hub := sentry.CurrentHub().Clone()
+			hub.Scope().SetRequest(r)
+			if id := requestIDFromContext(r.Context()); id != "" {
+				hub.Scope().SetTag("request_id", id)
+			}
+			hub.Recover(recovered)
+			writeJSON(w, http.StatusInternalServerError, ErrorResponse{Message: "internal server error"})
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	neturl "net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+type recordingTransport struct {
+	mu     sync.Mutex
+	events []*sentry.Event
+}
+
+func (t *recordingTransport) Configure(sentry.ClientOptions)            {}
+func (t *recordingTransport) Flush(time.Duration) bool                  { return true }
+func (t *recordingTransport) FlushWithContext(ctx context.Context) bool { return true }
+func (t *recordingTransport) Close()                                    {}
+
+func (t *recordingTransport) SendEvent(event *sentry.Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = append(t.events, event)
+}
+
+func useRecordingSentry(t *testing.T) *recordingTransport {
+	t.Helper()
+	transport := &recordingTransport{}
+	client, err := sentry.NewClient(sentry.ClientOptions{Dsn: "https://key@sentry.example.com/1", Transport: transport})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.CurrentHub()
+	previous := hub.Client()
+	hub.BindClient(client)
+	t.Cleanup(func() { hub.BindClient(previous) })
+	return transport
+}
+
+func TestRecoverMiddleware(t *testing.T) {
+	transport := useRecordingSentry(t)
+	handler := requestIDMiddleware(recoverMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})))
+
+	req := httptest.NewRequest("GET", "/weather/01310100", nil)
+	req.Header.Set(requestIDHeader, "panic-1")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", rec.Code)
+	}
+	if len(transport.events) != 1 || transport.events[0].Tags["request_id"] != "panic-1" {
+		t.Fatalf("Expected one event tagged with the request ID, got %+v", transport.events)
+	}
+}
+
+func TestCaptureUpstreamError(t *testing.T) {
+	t.Run("Sem DSN não envia nada", func(t *testing.T) {
+		captureUpstreamError(context.Background(), errors.New("weather API error: 502"), "weatherapi", "01310100", "São Paulo")
+	})
+
+	t.Run("Evento marcado com CEP, cidade, provedor e request ID", func(t *testing.T) {
+		transport := useRecordingSentry(t)
+		ctx := context.WithValue(context.Background(), requestIDContextKey, "req-7")
+		captureUpstreamError(ctx, errors.New("weather API error: 502"), "weatherapi", "01310100", "São Paulo")
+
+		if len(transport.events) != 1 {
+			t.Fatalf("Expected 1 event, got %d", len(transport.events))
+		}
+		expected := map[string]string{"provider": "weatherapi", "cep": "01310100", "city": "São Paulo", "request_id": "req-7"}
+		for tag, value := range expected {
+			if got := transport.events[0].Tags[tag]; got != value {
+				t.Errorf("Expected tag %s=%q, got %q", tag, value, got)
+			}
+		}
+	})
+
+	t.Run("Chave da API não vai para o Sentry", func(t *testing.T) {
+		transport := useRecordingSentry(t)
+		err := &neturl.Error{Op: "Get", URL: "http://127.0.0.1:1/forecast.json?key=secret-key&q=Sao+Paulo", Err: errors.New("connection refused")}
+		captureUpstreamError(context.Background(), err, "weatherapi", "01310100", "São Paulo")
+
+		if len(transport.events) != 1 {
+			t.Fatalf("Expected 1 event, got %d", len(transport.events))
+		}
+		if len(transport.events[0].Exception) == 0 {
+			t.Fatal("Expected the error in the event")
+		}
+		for _, exception := range transport.events[0].Exception {
+			if strings.Contains(exception.Value, "secret-key") {
+				t.Errorf("Expected the API key to be redacted, got %q", exception.Value)
+			}
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const unixSocketPrefix = "unix://"
+
+// ServerPolicy bounds how long a client may hold a connection. WriteTimeout
+// covers the whole handler, so it must stay above REQUEST_BUDGET and the
+// address validation timeout; a zero value disables that limit.
+type ServerPolicy struct {
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+}
+
+func newHTTPServer(addr string, handler http.Handler, policy ServerPolicy) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: policy.ReadHeaderTimeout,
+		ReadTimeout:       policy.ReadTimeout,
+		WriteTimeout:      policy.WriteTimeout,
+		IdleTimeout:       policy.IdleTimeout,
+		MaxHeaderBytes:    policy.MaxHeaderBytes,
+	}
+}
+
+// listen opens addr, which is either a TCP address or unix:///path/to.sock.
+// A socket file left behind by a previous run is removed first; mode sets
+// who may connect, typically the reverse proxy's group.
+func listen(addr string, mode fs.FileMode) (net.Listener, error) {
+	path, ok := strings.CutPrefix(addr, unixSocketPrefix)
+	if !ok {
+		return net.Listen("tcp", addr)
+	}
+	if info, err := os.Stat(path); err == nil {
+		if info.Mode()&fs.ModeSocket == 0 {
+			return nil, errors.New("listen path exists and is not a socket: " + path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
+
+// internalComponent serves the internal routes (metrics, admin and
+// admin-mounted pprof) on their own listener, meant to be reachable only
+// from inside the network.
+func internalComponent(addr string, handler http.Handler, cfg Config) Component {
+	server := newHTTPServer(addr, handler, cfg.Server)
+	return Component{
+		Name: "internal-listener",
+		Start: func(ctx context.Context) error {
+			listener, err := listen(addr, cfg.ListenSocketMode)
+			if err != nil {
+				return err
+			}
+			go func() {
+				if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					slog.Error("Internal listener failed", "error", err)
+				}
+			}()
+			slog.Info("Internal listener started", "addr", addr)
+			return nil
+		},
+		Stop: server.Shutdown,
+	}
+}
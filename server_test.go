@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewHTTPServer(t *testing.T) {
+	server := newHTTPServer("", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}), ServerPolicy{ReadHeaderTimeout: 100 * time.Millisecond, MaxHeaderBytes: 1024})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(ln)
+	t.Cleanup(func() { server.Close() })
+
+	t.Run("Cabeçalho lento é desconectado", func(t *testing.T) {
+		conn, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		conn.Write([]byte("GET / HTTP/1.1\r\nHost: x\r\n"))
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		start := time.Now()
+		buf := make([]byte, 1)
+		for {
+			if _, err := conn.Read(buf); err != nil {
+				break
+			}
+		}
+		if elapsed := time.Since(start); elapsed >= time.Second {
+			t.Errorf("Expected connection closed by ReadHeaderTimeout, waited %v", elapsed)
+		}
+	})
+
+	t.Run("Cabeçalho grande é rejeitado", func(t *testing.T) {
+		conn, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		conn.Write([]byte("GET / HTTP/1.1\r\nHost: x\r\nX-Big: " + strings.Repeat("a", 8192) + "\r\n\r\n"))
+		resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusRequestHeaderFieldsTooLarge {
+			t.Errorf("Expected status 431, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestListen(t *testing.T) {
+	t.Run("Socket unix substitui arquivo antigo", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "weather.sock")
+		stale, err := net.Listen("unix", path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stale.(*net.UnixListener).SetUnlinkOnClose(false)
+		stale.Close()
+
+		ln, err := listen("unix://"+path, 0o660)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})}
+		go server.Serve(ln)
+		defer server.Close()
+
+		if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o660 {
+			t.Errorf("Expected socket with mode 0660, got %v (%v)", info.Mode(), err)
+		}
+		client := &http.Client{Transport: &http.Transport{DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		}}}
+		resp, err := client.Get("http://unix/status")
+		if err != nil {
+			t.Fatalf("Expected response over unix socket, got %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNoContent {
+			t.Errorf("Expected status 204, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("Arquivo comum não é removido", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "weather.sock")
+		os.WriteFile(path, []byte("data"), 0o600)
+		if _, err := listen("unix://"+path, 0o660); err == nil {
+			t.Error("Expected error for a regular file")
+		}
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Expected regular file to be kept, got %v", err)
+		}
+	})
+
+	t.Run("Endereço TCP", func(t *testing.T) {
+		ln, err := listen("127.0.0.1:0", 0o660)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		defer ln.Close()
+		if ln.Addr().Network() != "tcp" {
+			t.Errorf("Expected tcp listener, got %s", ln.Addr().Network())
+		}
+	})
+}
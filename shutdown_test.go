@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestServe_GracefulShutdown(t *testing.T) {
+	tests := []struct {
+		name       string
+		work       time.Duration
+		drain      time.Duration
+		expectResp bool
+	}{
+		{"Requisição em andamento é concluída", 50 * time.Millisecond, time.Second, true},
+		{"Requisição cortada após o prazo", time.Second, 50 * time.Millisecond, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			started := make(chan struct{})
+			server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				close(started)
+				time.Sleep(tt.work)
+				w.WriteHeader(http.StatusOK)
+			})}
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			served := make(chan error, 1)
+			go func() { served <- serve(ctx, server, ln, tt.drain) }()
+
+			responded := make(chan error, 1)
+			go func() {
+				resp, err := http.Get("http://" + ln.Addr().String())
+				if err == nil {
+					resp.Body.Close()
+				}
+				responded <- err
+			}()
+			<-started
+			cancel()
+
+			if err := <-served; err != nil {
+				t.Errorf("Expected clean shutdown, got %v", err)
+			}
+			if err := <-responded; (err == nil) != tt.expectResp {
+				t.Errorf("Expected response %v, got error %v", tt.expectResp, err)
+			}
+			if _, err := net.DialTimeout("tcp", ln.Addr().String(), 100*time.Millisecond); err == nil {
+				t.Error("Expected listener to be closed")
+			}
+		})
+	}
+}
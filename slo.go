@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	sloBuckets = 60
+	// sloMinRequests keeps a handful of slow requests right after a deploy
+	// from reading as a budget burn.
+	sloMinRequests = 20
+)
+
+// SLOPolicy is a latency objective for weather lookups: Objective of the
+// requests over Window must succeed within LatencyTarget. A zero
+// LatencyTarget disables tracking.
+type SLOPolicy struct {
+	LatencyTarget time.Duration
+	Objective     float64
+	Window        time.Duration
+	BurnRateAlert float64
+}
+
+// validate rejects settings the tracker cannot compute with: a window
+// shorter than one nanosecond per bucket, or an objective that leaves no (or
+// a negative) error budget.
+func (p SLOPolicy) validate() error {
+	if p.LatencyTarget <= 0 {
+		return nil
+	}
+	if p.Objective <= 0 || p.Objective >= 1 {
+		return fmt.Errorf("SLO_OBJECTIVE must be between 0 and 1, got %v", p.Objective)
+	}
+	if p.Window < sloBuckets {
+		return fmt.Errorf("SLO_WINDOW must be at least %s, got %s", time.Duration(sloBuckets), p.Window)
+	}
+	return nil
+}
+
+type SLOStatus struct {
+	LatencyTarget string  `json:"latency_target"`
+	Objective     float64 `json:"objective"`
+	Window        string  `json:"window"`
+	Requests      int     `json:"requests"`
+	Compliance    float64 `json:"compliance"`
+	BurnRate      float64 `json:"burn_rate"`
+	Alerting      bool    `json:"alerting"`
+}
+
+type sloBucket struct {
+	start time.Time
+	total int
+	good  int
+}
+
+// sloTracker keeps a rolling window split into sloBuckets slices. A request
+// is good when it did not fail with a 5xx and finished within the target;
+// the burn rate is the bad ratio divided by the error budget (1-Objective).
+type sloTracker struct {
+	mu       sync.Mutex
+	policy   SLOPolicy
+	buckets  [sloBuckets]sloBucket
+	alerting bool
+	now      func() time.Time
+}
+
+func newSLOTracker(policy SLOPolicy) *sloTracker {
+	return &sloTracker{policy: policy, now: time.Now}
+}
+
+func (t *sloTracker) record(latency time.Duration, status int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	width := t.policy.Window / sloBuckets
+	start := now.Truncate(width)
+	b := &t.buckets[(start.UnixNano()/int64(width))%sloBuckets]
+	if !b.start.Equal(start) {
+		*b = sloBucket{start: start}
+	}
+	b.total++
+	if status < http.StatusInternalServerError && latency <= t.policy.LatencyTarget {
+		b.good++
+	}
+
+	current := t.statusLocked(now)
+	switch {
+	case !t.alerting && current.Requests >= sloMinRequests && current.BurnRate >= t.policy.BurnRateAlert:
+		t.alerting = true
+		slog.Error("ALERT: SLO error budget burning fast", "burn_rate", current.BurnRate, "compliance", current.Compliance,
+			"objective", t.policy.Objective, "latency_target", t.policy.LatencyTarget)
+	case t.alerting && current.BurnRate < t.policy.BurnRateAlert:
+		t.alerting = false
+		slog.Info("SLO burn rate back under threshold", "burn_rate", current.BurnRate)
+	}
+}
+
+func (t *sloTracker) status() SLOStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.statusLocked(t.now())
+}
+
+func (t *sloTracker) statusLocked(now time.Time) SLOStatus {
+	status := SLOStatus{
+		LatencyTarget: t.policy.LatencyTarget.String(),
+		Objective:     t.policy.Objective,
+		Window:        t.policy.Window.String(),
+		Compliance:    1,
+		Alerting:      t.alerting,
+	}
+	good := 0
+	for _, b := range t.buckets {
+		if now.Sub(b.start) < t.policy.Window {
+			status.Requests += b.total
+			good += b.good
+		}
+	}
+	if status.Requests > 0 {
+		status.Compliance = float64(good) / float64(status.Requests)
+	}
+	status.BurnRate = (1 - status.Compliance) / (1 - t.policy.Objective)
+	return status
+}
+
+func (t *sloTracker) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+		t.record(time.Since(start), recorder.status)
+	})
+}
+
+// collectors exposes the rolling compliance and burn rate as gauges, read
+// at scrape time.
+func (t *sloTracker) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "projetodeploy_slo_compliance_ratio",
+			Help: "Share of weather lookups within the latency SLO over the rolling window.",
+		}, func() float64 { return t.status().Compliance }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "projetodeploy_slo_burn_rate",
+			Help: "Rate at which the latency SLO error budget is being spent; 1 spends it exactly over the window.",
+		}, func() float64 { return t.status().BurnRate }),
+	}
+}
+
+type StatusResponse struct {
+	SLO *SLOStatus `json:"slo,omitempty"`
+}
+
+func (app *App) handleStatus(w http.ResponseWriter, r *http.Request) {
+	var response StatusResponse
+	if app.slo != nil {
+		status := app.slo.status()
+		response.SLO = &status
+	}
+	writeJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSLOTracker(t *testing.T) {
+	now := time.Date(2024, 5, 17, 13, 0, 0, 0, time.UTC)
+	policy := SLOPolicy{LatencyTarget: 800 * time.Millisecond, Objective: 0.99, Window: time.Hour, BurnRateAlert: 2}
+	newTracker := func() *sloTracker {
+		tracker := newSLOTracker(policy)
+		tracker.now = func() time.Time { return now }
+		return tracker
+	}
+
+	t.Run("Conformidade e taxa de queima", func(t *testing.T) {
+		tracker := newTracker()
+		for i := 0; i < 95; i++ {
+			tracker.record(100*time.Millisecond, http.StatusOK)
+		}
+		for i := 0; i < 3; i++ {
+			tracker.record(2*time.Second, http.StatusOK)
+		}
+		tracker.record(100*time.Millisecond, http.StatusInternalServerError)
+		tracker.record(100*time.Millisecond, http.StatusNotFound)
+
+		status := tracker.status()
+		if status.Requests != 100 || math.Abs(status.Compliance-0.96) > 1e-9 {
+			t.Errorf("Expected 100 requests at 0.96 compliance, got %d at %v", status.Requests, status.Compliance)
+		}
+		if math.Abs(status.BurnRate-4) > 1e-9 || !status.Alerting {
+			t.Errorf("Expected burn rate 4 and alerting, got %v alerting=%v", status.BurnRate, status.Alerting)
+		}
+	})
+
+	t.Run("Poucas requisições não disparam alerta", func(t *testing.T) {
+		tracker := newTracker()
+		tracker.record(2*time.Second, http.StatusOK)
+		if status := tracker.status(); status.Alerting {
+			t.Errorf("Expected no alert below %d requests, got %+v", sloMinRequests, status)
+		}
+	})
+
+	t.Run("Janela deslizante descarta requisições antigas", func(t *testing.T) {
+		tracker := newTracker()
+		for i := 0; i < 30; i++ {
+			tracker.record(2*time.Second, http.StatusOK)
+		}
+		now = now.Add(time.Hour + time.Minute)
+		tracker.record(100*time.Millisecond, http.StatusOK)
+		status := tracker.status()
+		if status.Requests != 1 || status.Compliance != 1 || status.Alerting {
+			t.Errorf("Expected only the recent request to count and the alert to clear, got %+v", status)
+		}
+	})
+}
+
+func TestHandleStatus(t *testing.T) {
+	env := newE2EEnv(t, func(cfg *Config) {
+		cfg.SLO = SLOPolicy{LatencyTarget: time.Second, Objective: 0.99, Window: time.Hour, BurnRateAlert: 14.4}
+	})
+	env.get(t, "/weather/01310100", nil)
+
+	resp, body := env.get(t, "/status", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+	var status StatusResponse
+	if err := json.Unmarshal(body, &status); err != nil {
+		t.Fatal(err)
+	}
+	if status.SLO == nil || status.SLO.Requests != 1 || status.SLO.Compliance != 1 {
+		t.Errorf("Expected one compliant request, got %s", body)
+	}
+
+	_, metrics := env.get(t, "/metrics", nil)
+	if !strings.Contains(string(metrics), "projetodeploy_slo_burn_rate 0") {
+		t.Errorf("Expected burn rate gauge in /metrics")
+	}
+}
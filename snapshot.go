@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const (
+	snapshotVersion = 1
+	// maxSnapshotBodyLen leaves room for a full cache export while keeping
+	// a runaway upload from exhausting memory.
+	maxSnapshotBodyLen = 64 << 20
+)
+
+// CacheSnapshot is the portable form of the caches, used to start a standby
+// instance warm. CEP entries get a fresh TTL on import; weather entries keep
+// their original expiry.
+type CacheSnapshot struct {
+	Version   int                              `json:"version"`
+	CreatedAt time.Time                        `json:"created_at"`
+	CEPs      map[string]*Address              `json:"ceps"`
+	Weather   []lruSnapshotEntry[*Observation] `json:"weather"`
+}
+
+type SnapshotImportResponse struct {
+	CEPs    int `json:"ceps"`
+	Weather int `json:"weather"`
+}
+
+func (app *App) handleSnapshotExport(w http.ResponseWriter, r *http.Request) {
+	snapshot := CacheSnapshot{Version: snapshotVersion, CreatedAt: time.Now().UTC(), CEPs: map[string]*Address{}}
+	if app.cepCache != nil {
+		entries, err := app.cepCache.Entries(r.Context())
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error exporting CEP cache", "error", err)
+			writeJSON(w, http.StatusBadGateway, ErrorResponse{Message: "error exporting cep cache"})
+			return
+		}
+		snapshot.CEPs = entries
+	}
+	if app.weatherCache != nil {
+		snapshot.Weather = app.weatherCache.Snapshot()
+	}
+	writeJSON(w, http.StatusOK, snapshot)
+}
+
+func (app *App) handleSnapshotImport(w http.ResponseWriter, r *http.Request) {
+	var snapshot CacheSnapshot
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSnapshotBodyLen)).Decode(&snapshot); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Message: "invalid snapshot"})
+		return
+	}
+	if snapshot.Version != snapshotVersion {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Message: "unsupported snapshot version"})
+		return
+	}
+	// Lookups key the cache by the normalized CEP; an entry stored under
+	// "01001-000" would never be hit.
+	ceps := make(map[string]*Address, len(snapshot.CEPs))
+	for cep, address := range snapshot.CEPs {
+		if !isValidCEP(cep) {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{Message: fmt.Sprintf("invalid zipcode in snapshot: %q", cep)})
+			return
+		}
+		if address != nil {
+			ceps[normalizeCEP(cep)] = address
+		}
+	}
+	var imported SnapshotImportResponse
+	if app.cepCache != nil {
+		for cep, address := range ceps {
+			if err := app.cepCache.Set(r.Context(), cep, address); err != nil {
+				slog.ErrorContext(r.Context(), "Error importing CEP cache", "error", err)
+				writeJSON(w, http.StatusBadGateway, ErrorResponse{Message: "error importing cep cache"})
+				return
+			}
+			imported.CEPs++
+		}
+	}
+	if app.weatherCache != nil {
+		for _, entry := range snapshot.Weather {
+			if entry.Value == nil {
+				continue
+			}
+			app.weatherCache.Restore(entry.Key, entry.Value, entry.ExpiresAt)
+			imported.Weather++
+		}
+	}
+	slog.InfoContext(r.Context(), "Admin imported snapshot", "created_at", snapshot.CreatedAt.Format(time.RFC3339), "ceps", imported.CEPs, "weather", imported.Weather)
+	writeJSON(w, http.StatusOK, imported)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func (env *e2eEnv) post(t *testing.T, path, token string, body []byte) (*http.Response, []byte) {
+	t.Helper()
+	req, err := http.NewRequest("POST", env.server.URL+path, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, respBody
+}
+
+func TestE2E_SnapshotExportImport(t *testing.T) {
+	newReplica := func() *e2eEnv {
+		redisServer := miniredis.RunT(t)
+		return newE2EEnv(t, func(cfg *Config) {
+			cfg.AdminToken = "admin-secret"
+			cfg.RedisAddr = redisServer.Addr()
+			cfg.CEPCacheTTL = time.Hour
+		})
+	}
+	primary, standby := newReplica(), newReplica()
+
+	primary.get(t, "/weather/01310100", nil)
+	primary.get(t, "/weather/20040002", nil)
+
+	resp, snapshot := primary.get(t, "/admin/snapshot", map[string]string{"Authorization": "Bearer admin-secret"})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", resp.StatusCode, snapshot)
+	}
+
+	resp, body := standby.post(t, "/admin/snapshot", "admin-secret", snapshot)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", resp.StatusCode, body)
+	}
+	var imported SnapshotImportResponse
+	if err := json.Unmarshal(body, &imported); err != nil {
+		t.Fatalf("Error parsing response: %v", err)
+	}
+	if imported.CEPs != 2 || imported.Weather != 2 {
+		t.Errorf("Expected 2 CEPs and 2 weather entries, got %+v", imported)
+	}
+
+	resp, _ = standby.get(t, "/weather/20040002", nil)
+	if resp.Header.Get("X-Cache") != "HIT" {
+		t.Errorf("Expected X-Cache HIT on the standby, got %s", resp.Header.Get("X-Cache"))
+	}
+	if calls := atomic.LoadInt32(&standby.viaCEP.calls) + atomic.LoadInt32(&standby.weatherAPI.calls); calls != 0 {
+		t.Errorf("Expected the standby to serve from the snapshot, got %d upstream calls", calls)
+	}
+
+	t.Run("Snapshot inválido", func(t *testing.T) {
+		resp, body := standby.post(t, "/admin/snapshot", "admin-secret", []byte(`{"version": 99}`))
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("Expected status 400, got %d", resp.StatusCode)
+		}
+		expectMessage(t, body, "unsupported snapshot version")
+	})
+
+	t.Run("CEP inválido no snapshot", func(t *testing.T) {
+		resp, body := standby.post(t, "/admin/snapshot", "admin-secret", []byte(`{"version": 1, "ceps": {"0100": {"cep": "0100"}}}`))
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("Expected status 400, got %d", resp.StatusCode)
+		}
+		expectMessage(t, body, `invalid zipcode in snapshot: "0100"`)
+	})
+
+	t.Run("CEP com hífen é normalizado", func(t *testing.T) {
+		snapshot := []byte(`{"version": 1, "ceps": {"01001-000": {"cep": "01001000", "city": "São Paulo", "state": "SP"}}}`)
+		if resp, body := standby.post(t, "/admin/snapshot", "admin-secret", snapshot); resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", resp.StatusCode, body)
+		}
+		if _, found, _ := standby.app.cepCache.Get(context.Background(), "01001000"); !found {
+			t.Error("Expected the imported entry under the normalized CEP")
+		}
+	})
+}
+
+func TestLRUCache_SnapshotRestore(t *testing.T) {
+	now := time.Date(2024, 5, 17, 13, 0, 0, 0, time.UTC)
+	source := newLRUCache[int](10, time.Minute)
+	source.now = func() time.Time { return now }
+	source.Set("a", 1)
+	now = now.Add(30 * time.Second)
+	source.Set("b", 2)
+
+	target := newLRUCache[int](10, time.Minute)
+	target.now = func() time.Time { return now }
+	for _, entry := range source.Snapshot() {
+		target.Restore(entry.Key, entry.Value, entry.ExpiresAt)
+	}
+
+	now = now.Add(45 * time.Second)
+	if _, ok := target.Get("a"); ok {
+		t.Error("Expected 'a' to keep its original expiry")
+	}
+	if value, ok := target.Get("b"); !ok || value != 2 {
+		t.Errorf("Expected 'b' = 2, got %d (found=%v)", value, ok)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	mathrand "math/rand/v2"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	cepLeaseTTL          = 5 * time.Second
+	cepLeasePollInterval = 50 * time.Millisecond
+)
+
+// jitteredTTL spreads expirations of entries written together by up to
+// ±jitter of ttl, so they do not all expire in the same instant.
+func jitteredTTL(ttl time.Duration, jitter float64) time.Duration {
+	if jitter <= 0 || ttl <= 0 {
+		return ttl
+	}
+	return ttl + time.Duration((mathrand.Float64()*2-1)*jitter*float64(ttl))
+}
+
+// cepLeaser is implemented by shared caches that can elect a single replica
+// to refresh a missing entry. Singleflight already does this within one
+// process; the lease extends it across replicas.
+type cepLeaser interface {
+	AcquireLease(ctx context.Context, cep string) (token string, acquired bool, err error)
+	ReleaseLease(ctx context.Context, cep, token string) error
+	LeaseHeld(ctx context.Context, cep string) (bool, error)
+}
+
+func cepLeaseKey(cep string) string {
+	return "lease:cep:" + cep
+}
+
+var releaseLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+func (c *RedisCEPCache) AcquireLease(ctx context.Context, cep string) (string, bool, error) {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	token := hex.EncodeToString(buf)
+	acquired, err := c.client.SetNX(ctx, cepLeaseKey(cep), token, cepLeaseTTL).Result()
+	return token, acquired, err
+}
+
+// ReleaseLease only deletes the lease if it is still ours; an expired lease
+// may already belong to another replica.
+func (c *RedisCEPCache) ReleaseLease(ctx context.Context, cep, token string) error {
+	return releaseLeaseScript.Run(ctx, c.client, []string{cepLeaseKey(cep)}, token).Err()
+}
+
+func (c *RedisCEPCache) LeaseHeld(ctx context.Context, cep string) (bool, error) {
+	n, err := c.client.Exists(ctx, cepLeaseKey(cep)).Result()
+	return n > 0, err
+}
+
+func (c *TieredCEPCache) AcquireLease(ctx context.Context, cep string) (string, bool, error) {
+	if leaser, ok := c.l2.(cepLeaser); ok {
+		return leaser.AcquireLease(ctx, cep)
+	}
+	return "", true, nil
+}
+
+func (c *TieredCEPCache) ReleaseLease(ctx context.Context, cep, token string) error {
+	if leaser, ok := c.l2.(cepLeaser); ok {
+		return leaser.ReleaseLease(ctx, cep, token)
+	}
+	return nil
+}
+
+func (c *TieredCEPCache) LeaseHeld(ctx context.Context, cep string) (bool, error) {
+	if leaser, ok := c.l2.(cepLeaser); ok {
+		return leaser.LeaseHeld(ctx, cep)
+	}
+	return false, nil
+}
+
+// awaitLease either wins the lease, and returns a release func the caller
+// must run after writing the cache, or waits for the winner to populate the
+// cache. If the winner releases the lease without caching anything (the CEP
+// was not found, or the lookup failed) or takes longer than the lease, the
+// caller fetches on its own rather than waiting forever.
+func (s *CEPService) awaitLease(ctx context.Context, leaser cepLeaser, cep string) (*Address, func()) {
+	token, acquired, err := leaser.AcquireLease(ctx, cep)
+	if err != nil {
+		slog.WarnContext(ctx, "Error acquiring CEP cache lease", "error", err)
+		return nil, func() {}
+	}
+	if acquired {
+		return nil, func() {
+			if err := leaser.ReleaseLease(context.Background(), cep, token); err != nil {
+				slog.WarnContext(ctx, "Error releasing CEP cache lease", "error", err)
+			}
+		}
+	}
+	ticker := time.NewTicker(cepLeasePollInterval)
+	defer ticker.Stop()
+	timeout := time.After(cepLeaseTTL)
+	for {
+		select {
+		case <-ticker.C:
+			if address, found, _ := s.cache.Get(ctx, cep); found {
+				return address, nil
+			}
+			if held, err := leaser.LeaseHeld(ctx, cep); err == nil && !held {
+				return nil, func() {}
+			}
+		case <-timeout:
+			return nil, func() {}
+		case <-ctx.Done():
+			return nil, func() {}
+		}
+	}
+}
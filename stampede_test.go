@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestJitteredTTL(t *testing.T) {
+	if ttl := jitteredTTL(time.Minute, 0); ttl != time.Minute {
+		t.Errorf("Expected TTL unchanged without jitter, got %s", ttl)
+	}
+	for i := 0; i < 100; i++ {
+		if ttl := jitteredTTL(time.Minute, 0.1); ttl < 54*time.Second || ttl > 66*time.Second {
+			t.Fatalf("Expected TTL within ±10%%, got %s", ttl)
+		}
+	}
+}
+
+func TestCEPService_Lease(t *testing.T) {
+	ctx := context.Background()
+	cepURL := "https://viacep.com.br/ws/01310100/json/"
+
+	t.Run("Aguarda a réplica que detém o lease", func(t *testing.T) {
+		cache, _ := newTestRedisCEPCache(t, time.Hour)
+		mockClient := NewMockHTTPClient()
+		mockClient.AddError(cepURL, errors.New("upstream should not be called"))
+		service := NewCEPService(mockClient)
+		service.cache = cache
+
+		token, acquired, err := cache.AcquireLease(ctx, "01310100")
+		if err != nil || !acquired {
+			t.Fatalf("Expected to acquire lease, got acquired=%v err=%v", acquired, err)
+		}
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			cache.Set(ctx, "01310100", &Address{CEP: "01310100", City: "São Paulo", State: "SP"})
+			cache.ReleaseLease(ctx, "01310100", token)
+		}()
+
+		result, err := service.GetCEPInfo(context.Background(), "01310100")
+		if err != nil {
+			t.Fatalf("Expected the leaseholder's result, got %v", err)
+		}
+		if result.State != "SP" {
+			t.Errorf("Expected state 'SP', got '%s'", result.State)
+		}
+	})
+
+	t.Run("Lease liberado sem resultado não prende quem aguarda", func(t *testing.T) {
+		cache, _ := newTestRedisCEPCache(t, time.Hour)
+		mockClient := NewMockHTTPClient()
+		mockClient.AddResponse(cepURL, 200, `{"erro": true}`)
+		service := NewCEPService(mockClient)
+		service.cache = cache
+
+		token, _, _ := cache.AcquireLease(ctx, "01310100")
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			cache.ReleaseLease(ctx, "01310100", token)
+		}()
+
+		start := time.Now()
+		if _, err := service.GetCEPInfo(context.Background(), "01310100"); !errors.Is(err, ErrCEPNotFound) {
+			t.Fatalf("Expected ErrCEPNotFound, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed >= cepLeaseTTL {
+			t.Errorf("Expected waiter to stop when the lease was released, waited %s", elapsed)
+		}
+	})
+
+	t.Run("Lease é liberado após a consulta", func(t *testing.T) {
+		cache, server := newTestRedisCEPCache(t, time.Hour)
+		mockClient := NewMockHTTPClient()
+		mockClient.AddResponse(cepURL, 200, `{"cep": "01310-100", "localidade": "São Paulo", "uf": "SP"}`)
+		service := NewCEPService(mockClient)
+		service.cache = cache
+
+		if _, err := service.GetCEPInfo(context.Background(), "01310100"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if server.Exists(cepLeaseKey("01310100")) {
+			t.Error("Expected lease to be released")
+		}
+	})
+
+	t.Run("Lease alheio não é liberado", func(t *testing.T) {
+		cache, server := newTestRedisCEPCache(t, time.Hour)
+		cache.AcquireLease(ctx, "01310100")
+
+		if err := cache.ReleaseLease(ctx, "01310100", "someone-else"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !server.Exists(cepLeaseKey("01310100")) {
+			t.Error("Expected lease held by another replica to remain")
+		}
+	})
+}
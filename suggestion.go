@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+)
+
+// SuggestionRule matches an observation when every criterion it sets
+// matches; a list criterion matches any of its values. Rules sharing an ID
+// express alternatives, and an ID is suggested at most once.
+type SuggestionRule struct {
+	ID              string            `json:"id"`
+	Conditions      []string          `json:"conditions,omitempty"`
+	Comfort         []string          `json:"comfort,omitempty"`
+	MinUV           float64           `json:"min_uv,omitempty"`
+	MinChanceOfRain int               `json:"min_chance_of_rain,omitempty"`
+	Text            map[string]string `json:"text"`
+}
+
+type Suggestion struct {
+	ID   string `json:"id"`
+	Text string `json:"text"`
+}
+
+type SuggestionResponse struct {
+	CEP         string         `json:"cep"`
+	Condition   *ConditionInfo `json:"condition"`
+	Comfort     string         `json:"comfort"`
+	Suggestions []Suggestion   `json:"suggestions"`
+}
+
+var rainConditions = []string{"drizzle", "light_rain", "rain", "heavy_rain", "freezing_rain", "sleet", "thunderstorm"}
+
+var defaultSuggestionRules = []SuggestionRule{
+	{ID: "umbrella", Conditions: rainConditions, Text: map[string]string{langPortuguese: "Leve guarda-chuva", langEnglish: "Take an umbrella", langSpanish: "Lleve paraguas"}},
+	{ID: "umbrella", MinChanceOfRain: 50, Text: map[string]string{langPortuguese: "Leve guarda-chuva", langEnglish: "Take an umbrella", langSpanish: "Lleve paraguas"}},
+	{ID: "stay_indoors", Conditions: []string{"thunderstorm"}, Text: map[string]string{langPortuguese: "Evite atividades ao ar livre", langEnglish: "Avoid outdoor activities", langSpanish: "Evite actividades al aire libre"}},
+	{ID: "hydrate", Comfort: []string{"warm", "hot"}, Text: map[string]string{langPortuguese: "Hidrate-se", langEnglish: "Stay hydrated", langSpanish: "Hidrátese"}},
+	{ID: "light_clothes", Comfort: []string{"hot"}, Text: map[string]string{langPortuguese: "Use roupas leves", langEnglish: "Wear light clothing", langSpanish: "Use ropa ligera"}},
+	{ID: "jacket", Comfort: []string{"cool"}, Text: map[string]string{langPortuguese: "Leve um casaco leve", langEnglish: "Bring a light jacket", langSpanish: "Lleve una chaqueta ligera"}},
+	{ID: "warm_clothes", Comfort: []string{"cold"}, Text: map[string]string{langPortuguese: "Agasalhe-se bem", langEnglish: "Dress warmly", langSpanish: "Abríguese bien"}},
+	{ID: "sunscreen", MinUV: 6, Text: map[string]string{langPortuguese: "Use protetor solar", langEnglish: "Wear sunscreen", langSpanish: "Use protector solar"}},
+	{ID: "sunglasses", Conditions: []string{"clear", "partly_cloudy"}, MinUV: 3, Text: map[string]string{langPortuguese: "Use óculos de sol", langEnglish: "Wear sunglasses", langSpanish: "Use gafas de sol"}},
+	{ID: "outdoors", Conditions: []string{"clear", "partly_cloudy", "cloudy"}, Comfort: []string{"comfortable"}, Text: map[string]string{langPortuguese: "Bom momento para atividades ao ar livre", langEnglish: "Good time for outdoor activities", langSpanish: "Buen momento para actividades al aire libre"}},
+}
+
+// comfortLevel is a temperature-only comfort index; providers do not give us
+// humidity or wind for every source.
+func comfortLevel(tempC float64) string {
+	switch {
+	case tempC < 12:
+		return "cold"
+	case tempC < 18:
+		return "cool"
+	case tempC < 26:
+		return "comfortable"
+	case tempC < 32:
+		return "warm"
+	default:
+		return "hot"
+	}
+}
+
+func (rule SuggestionRule) matches(observation *Observation, comfort string) bool {
+	if len(rule.Conditions) > 0 && !slices.Contains(rule.Conditions, observation.Condition) {
+		return false
+	}
+	if len(rule.Comfort) > 0 && !slices.Contains(rule.Comfort, comfort) {
+		return false
+	}
+	if rule.MinUV > 0 && (!observation.IsDay || observation.UV < rule.MinUV) {
+		return false
+	}
+	if rule.MinChanceOfRain > 0 && (observation.ChanceOfRain == nil || *observation.ChanceOfRain < rule.MinChanceOfRain) {
+		return false
+	}
+	return true
+}
+
+func suggest(rules []SuggestionRule, observation *Observation, lang string) []Suggestion {
+	comfort := comfortLevel(observation.TempC)
+	suggestions := []Suggestion{}
+	seen := make(map[string]bool)
+	for _, rule := range rules {
+		if seen[rule.ID] || !rule.matches(observation, comfort) {
+			continue
+		}
+		seen[rule.ID] = true
+		text, ok := rule.Text[lang]
+		if !ok {
+			text = rule.Text[defaultLang]
+		}
+		suggestions = append(suggestions, Suggestion{ID: rule.ID, Text: text})
+	}
+	return suggestions
+}
+
+// loadSuggestionRules reads a JSON array of rules, replacing the built-in
+// set. Every rule needs an ID, at least one criterion and a default-language
+// text.
+func loadSuggestionRules(path string) ([]SuggestionRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rules []SuggestionRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("invalid suggestion rules: %w", err)
+	}
+	for i, rule := range rules {
+		switch {
+		case rule.ID == "":
+			return nil, fmt.Errorf("suggestion rule %d has no id", i)
+		case len(rule.Conditions) == 0 && len(rule.Comfort) == 0 && rule.MinUV == 0 && rule.MinChanceOfRain == 0:
+			return nil, fmt.Errorf("suggestion rule %q has no criteria", rule.ID)
+		case rule.Text[defaultLang] == "":
+			return nil, fmt.Errorf("suggestion rule %q has no %s text", rule.ID, defaultLang)
+		}
+	}
+	return rules, nil
+}
+
+func (app *App) handleSuggestion(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := app.budget.start(r.Context())
+	defer cancel()
+	normalizedCEP, cepInfo, ok := app.resolveAddress(ctx, w, r)
+	if !ok {
+		return
+	}
+	observation, cacheStatus, err := app.weather.LookupTemperature(ctx, cepInfo)
+	switch {
+	case errors.Is(err, context.Canceled):
+		slog.InfoContext(ctx, "Client disconnected during weather lookup", "cep", normalizedCEP)
+		return
+	case errors.Is(err, context.DeadlineExceeded):
+		writeJSON(w, http.StatusGatewayTimeout, ErrorResponse{Message: "upstream timeout"})
+		return
+	case errors.Is(err, ErrWeatherQuotaExceeded):
+		writeRetryAfter(w, app.weather.RetryAfter(err))
+		writeJSON(w, http.StatusServiceUnavailable, ErrorResponse{Message: "weather provider quota exceeded"})
+		return
+	case errors.Is(err, ErrCircuitOpen):
+		writeRetryAfter(w, app.weather.RetryAfter(err))
+		writeJSON(w, http.StatusServiceUnavailable, ErrorResponse{Message: "weather provider unavailable"})
+		return
+	case err != nil:
+		slog.ErrorContext(ctx, "Error getting weather info", "cep", normalizedCEP, "city", cepInfo.City, "error", redactURLError(err))
+		captureUpstreamError(ctx, err, app.weatherProvider, normalizedCEP, cepInfo.City)
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{Message: "error getting weather information"})
+		return
+	}
+	lang := requestLanguage(r)
+	w.Header().Set("X-Cache", cacheStatus)
+	writeJSON(w, http.StatusOK, SuggestionResponse{
+		CEP:         normalizedCEP,
+		Condition:   newConditionInfo(observation, lang),
+		Comfort:     comfortLevel(observation.TempC),
+		Suggestions: suggest(app.suggestionRules, observation, lang),
+	})
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSuggest(t *testing.T) {
+	chance := func(n int) *int { return &n }
+	tests := []struct {
+		name        string
+		observation Observation
+		lang        string
+		expected    []string
+	}{
+		{"Chuva e calor", Observation{TempC: 33, Condition: "rain", IsDay: true, UV: 2}, langPortuguese, []string{"Leve guarda-chuva", "Hidrate-se", "Use roupas leves"}},
+		{"Chance de chuva sem chuva agora", Observation{TempC: 20, Condition: "cloudy", ChanceOfRain: chance(70)}, langEnglish, []string{"Take an umbrella", "Good time for outdoor activities"}},
+		{"Sol forte", Observation{TempC: 24, Condition: "clear", IsDay: true, UV: 8}, langSpanish, []string{"Use protector solar", "Use gafas de sol", "Buen momento para actividades al aire libre"}},
+		{"UV ignorado à noite", Observation{TempC: 10, Condition: "clear", UV: 8}, langPortuguese, []string{"Agasalhe-se bem"}},
+		{"Tempestade", Observation{TempC: 16, Condition: "thunderstorm"}, langPortuguese, []string{"Leve guarda-chuva", "Evite atividades ao ar livre", "Leve um casaco leve"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var texts []string
+			for _, s := range suggest(defaultSuggestionRules, &tt.observation, tt.lang) {
+				texts = append(texts, s.Text)
+			}
+			if strings.Join(texts, "|") != strings.Join(tt.expected, "|") {
+				t.Errorf("Expected %v, got %v", tt.expected, texts)
+			}
+		})
+	}
+}
+
+func TestLoadSuggestionRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"Regras válidas", `[{"id": "casaco", "comfort": ["cold"], "text": {"pt-BR": "Leve casaco"}}]`, ""},
+		{"JSON inválido", `[{"id": `, "invalid suggestion rules"},
+		{"Sem critérios", `[{"id": "sempre", "text": {"pt-BR": "Sempre"}}]`, `suggestion rule "sempre" has no criteria`},
+		{"Sem texto em português", `[{"id": "casaco", "comfort": ["cold"], "text": {"en": "Coat"}}]`, `suggestion rule "casaco" has no pt-BR text`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "rules.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			rules, err := loadSuggestionRules(path)
+			if tt.wantErr == "" {
+				if err != nil || len(rules) != 1 {
+					t.Errorf("Expected 1 rule, got %v (%v)", rules, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestHandleSuggestion(t *testing.T) {
+	env := newE2EEnv(t, nil)
+	env.app.suggestionRules = []SuggestionRule{
+		{ID: "parasol", Comfort: []string{"comfortable"}, Text: map[string]string{langPortuguese: "Leve guarda-sol", langEnglish: "Take a parasol"}},
+	}
+
+	resp, body := env.get(t, "/suggestion/01310100?lang=en", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", resp.StatusCode, body)
+	}
+	var response SuggestionResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		t.Fatalf("Error parsing response: %v", err)
+	}
+	if response.Comfort != "comfortable" || len(response.Suggestions) != 1 || response.Suggestions[0].Text != "Take a parasol" {
+		t.Errorf("Unexpected response %+v", response)
+	}
+
+	rec := httptest.NewRecorder()
+	env.app.setupRoutes().ServeHTTP(rec, httptest.NewRequest("GET", "/suggestion/123", nil))
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status 422, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// TieredCEPCache keeps hot CEPs in process memory (L1) in front of a shared
+// cache such as Redis (L2). Reads fall through to L2 and populate L1; writes
+// and deletes go to both tiers. L1 entries live for a short TTL so replicas
+// converge after another replica purges or overwrites a key.
+type TieredCEPCache struct {
+	l1 *lruCache[*Address]
+	l2 CEPCache
+}
+
+func NewTieredCEPCache(l2 CEPCache, l1Size int, l1TTL time.Duration) *TieredCEPCache {
+	l1 := newLRUCache[*Address](l1Size, l1TTL)
+	l1.sizeOf = addressSize
+	return &TieredCEPCache{l1: l1, l2: l2}
+}
+
+func (c *TieredCEPCache) Get(ctx context.Context, cep string) (*Address, bool, error) {
+	if address, ok := c.l1.Get(cep); ok {
+		return address, true, nil
+	}
+	address, found, err := c.l2.Get(ctx, cep)
+	if err != nil || !found {
+		return nil, false, err
+	}
+	c.l1.Set(cep, address)
+	return address, true, nil
+}
+
+func (c *TieredCEPCache) Set(ctx context.Context, cep string, address *Address) error {
+	c.l1.Set(cep, address)
+	return c.l2.Set(ctx, cep, address)
+}
+
+func (c *TieredCEPCache) Delete(ctx context.Context, cep string) error {
+	c.l1.Delete(cep)
+	return c.l2.Delete(ctx, cep)
+}
+
+func (c *TieredCEPCache) Flush(ctx context.Context) error {
+	c.l1.Purge()
+	return c.l2.Flush(ctx)
+}
+
+// Stats reports the L2 entry count with hits from either tier, and the
+// per-tier breakdown under Tiers.
+func (c *TieredCEPCache) Stats(ctx context.Context) (CacheStats, error) {
+	l1 := c.l1.Stats()
+	l2, err := c.l2.Stats(ctx)
+	if err != nil {
+		return CacheStats{}, err
+	}
+	stats := newCacheStats("tiered", l2.Entries, l1.Hits+l2.Hits, l2.Misses, l1.MemoryBytes+l2.MemoryBytes)
+	stats.Tiers = []CacheStats{l1, l2}
+	return stats, nil
+}
+
+func (c *TieredCEPCache) Entries(ctx context.Context) (map[string]*Address, error) {
+	return c.l2.Entries(ctx)
+}
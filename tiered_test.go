@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTieredCEPCache(t *testing.T) {
+	ctx := context.Background()
+	address := &Address{CEP: "01310100", City: "São Paulo", State: "SP"}
+
+	t.Run("Escrita alimenta as duas camadas", func(t *testing.T) {
+		l2, server := newTestRedisCEPCache(t, time.Hour)
+		cache := NewTieredCEPCache(l2, 10, time.Minute)
+		if err := cache.Set(ctx, "01310100", address); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		server.FlushAll()
+		if got, found, err := cache.Get(ctx, "01310100"); err != nil || !found || got.City != "São Paulo" {
+			t.Errorf("Expected L1 hit, got %+v found=%v err=%v", got, found, err)
+		}
+	})
+
+	t.Run("Leitura do L2 popula o L1", func(t *testing.T) {
+		l2, server := newTestRedisCEPCache(t, time.Hour)
+		l2.Set(ctx, "01310100", address)
+		cache := NewTieredCEPCache(l2, 10, time.Minute)
+
+		if _, found, _ := cache.Get(ctx, "01310100"); !found {
+			t.Fatal("Expected L2 hit")
+		}
+		server.FlushAll()
+		if _, found, _ := cache.Get(ctx, "01310100"); !found {
+			t.Error("Expected L1 hit after read-through")
+		}
+
+		stats, err := cache.Stats(ctx)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(stats.Tiers) != 2 || stats.Tiers[0].Hits != 1 || stats.Tiers[1].Hits != 1 {
+			t.Errorf("Unexpected per-tier stats: %+v", stats.Tiers)
+		}
+		if stats.Hits != 2 {
+			t.Errorf("Expected 2 hits across tiers, got %d", stats.Hits)
+		}
+	})
+
+	t.Run("Remoção afeta as duas camadas", func(t *testing.T) {
+		l2, _ := newTestRedisCEPCache(t, time.Hour)
+		cache := NewTieredCEPCache(l2, 10, time.Minute)
+		cache.Set(ctx, "01310100", address)
+
+		if err := cache.Delete(ctx, "01310100"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if _, found, _ := cache.Get(ctx, "01310100"); found {
+			t.Error("Expected miss after delete")
+		}
+	})
+}
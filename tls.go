@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// TLSPolicy enables HTTPS on the main listener, either from a certificate
+// and key on disk or from Let's Encrypt certificates obtained for
+// AutocertDomains. With neither set the server speaks plain HTTP, as it
+// does behind Cloud Run or a reverse proxy.
+type TLSPolicy struct {
+	CertFile         string
+	KeyFile          string
+	AutocertDomains  []string
+	AutocertCacheDir string
+	AutocertEmail    string
+	// RedirectAddr, when set, serves plain HTTP there: ACME HTTP-01
+	// challenges are answered and everything else is redirected to HTTPS.
+	RedirectAddr string
+}
+
+func (p TLSPolicy) enabled() bool {
+	return p.CertFile != "" || len(p.AutocertDomains) > 0
+}
+
+func (p TLSPolicy) validate() error {
+	switch {
+	case (p.CertFile == "") != (p.KeyFile == ""):
+		return errors.New("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	case p.CertFile != "" && len(p.AutocertDomains) > 0:
+		return errors.New("TLS_CERT_FILE and TLS_AUTOCERT_DOMAINS are mutually exclusive")
+	case p.RedirectAddr != "" && !p.enabled():
+		return errors.New("TLS_REDIRECT_ADDR requires TLS")
+	}
+	return nil
+}
+
+// newTLSConfig returns the server TLS configuration and, for autocert, the
+// handler that answers HTTP-01 challenges. Autocert also answers TLS-ALPN-01
+// on the HTTPS port itself, so the redirect listener is optional.
+func newTLSConfig(policy TLSPolicy) (*tls.Config, http.Handler, error) {
+	if policy.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(policy.CertFile, policy.KeyFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}, http.HandlerFunc(redirectToHTTPS), nil
+	}
+	manager := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(policy.AutocertDomains...),
+		Cache:      autocert.DirCache(policy.AutocertCacheDir),
+		Email:      policy.AutocertEmail,
+	}
+	config := manager.TLSConfig()
+	config.MinVersion = tls.VersionTLS12
+	return config, manager.HTTPHandler(nil), nil
+}
+
+func redirectToHTTPS(w http.ResponseWriter, r *http.Request) {
+	host, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		host = r.Host
+	}
+	http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+}
+
+func redirectComponent(addr string, handler http.Handler, policy ServerPolicy) Component {
+	server := newHTTPServer(addr, handler, policy)
+	return Component{
+		Name: "https-redirect",
+		Start: func(ctx context.Context) error {
+			listener, err := net.Listen("tcp", addr)
+			if err != nil {
+				return err
+			}
+			go func() {
+				if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					slog.Error("HTTPS redirect listener failed", "error", err)
+				}
+			}()
+			slog.Info("HTTPS redirect listening", "addr", listener.Addr().String())
+			return nil
+		},
+		Stop: server.Shutdown,
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func writeSelfSignedCert(t *testing.T) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+	return certFile, keyFile
+}
+
+func TestTLSPolicy_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  TLSPolicy
+		wantErr bool
+	}{
+		{"Desabilitado", TLSPolicy{}, false},
+		{"Certificado e chave", TLSPolicy{CertFile: "cert.pem", KeyFile: "key.pem"}, false},
+		{"Autocert", TLSPolicy{AutocertDomains: []string{"clima.example.com"}, RedirectAddr: ":80"}, false},
+		{"Certificado sem chave", TLSPolicy{CertFile: "cert.pem"}, true},
+		{"Arquivos e autocert juntos", TLSPolicy{CertFile: "cert.pem", KeyFile: "key.pem", AutocertDomains: []string{"clima.example.com"}}, true},
+		{"Redirecionamento sem TLS", TLSPolicy{RedirectAddr: ":80"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.policy.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	t.Run("Serve HTTPS com certificado em arquivo", func(t *testing.T) {
+		certFile, keyFile := writeSelfSignedCert(t)
+		config, _, err := newTLSConfig(TLSPolicy{CertFile: certFile, KeyFile: keyFile})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		server := newHTTPServer("", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}), ServerPolicy{})
+		server.TLSConfig = config
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go serve(ctx, server, ln, time.Second)
+
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+		resp, err := client.Get("https://" + ln.Addr().String())
+		if err != nil {
+			t.Fatalf("Expected HTTPS response, got %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNoContent || resp.TLS == nil {
+			t.Errorf("Expected status 204 over TLS, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("Arquivo de certificado ausente", func(t *testing.T) {
+		if _, _, err := newTLSConfig(TLSPolicy{CertFile: "/nonexistent/cert.pem", KeyFile: "/nonexistent/key.pem"}); err == nil {
+			t.Error("Expected error for missing certificate")
+		}
+	})
+
+	t.Run("Autocert responde desafios TLS-ALPN", func(t *testing.T) {
+		config, challenge, err := newTLSConfig(TLSPolicy{AutocertDomains: []string{"clima.example.com"}, AutocertCacheDir: t.TempDir()})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if config.GetCertificate == nil || !slices.Contains(config.NextProtos, "acme-tls/1") || challenge == nil {
+			t.Errorf("Expected autocert TLS config, got %+v", config)
+		}
+	})
+}
+
+func TestRedirectToHTTPS(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "http://clima.example.com:8080/weather/01310100?lang=en", nil)
+	redirectToHTTPS(rec, req)
+	if rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != "https://clima.example.com/weather/01310100?lang=en" {
+		t.Errorf("Unexpected redirect %d %s", rec.Code, rec.Header().Get("Location"))
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.43.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	tracerName         = "github.com/fabiuhp/projetodeploy"
+	defaultServiceName = "projetodeploy"
+)
+
+// tracingComponent installs W3C trace context propagation and, when an OTLP
+// endpoint is configured, a tracer provider exporting to it. The exporter
+// reads the standard OTEL_EXPORTER_OTLP_* variables itself.
+func tracingComponent(cfg Config) Component {
+	var provider *sdktrace.TracerProvider
+	return Component{
+		Name: "tracing",
+		Start: func(ctx context.Context) error {
+			otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+			if cfg.OTLPEndpoint == "" {
+				return nil
+			}
+			exporter, err := otlptracehttp.New(ctx)
+			if err != nil {
+				return err
+			}
+			// OTEL_SERVICE_NAME, read by WithFromEnv, overrides the default name.
+			res, err := resource.New(ctx,
+				resource.WithAttributes(semconv.ServiceName(defaultServiceName)),
+				resource.WithFromEnv(),
+				resource.WithTelemetrySDK(),
+			)
+			if err != nil {
+				return err
+			}
+			provider = sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+			otel.SetTracerProvider(provider)
+			slog.Info("Tracing enabled", "otlp_endpoint", cfg.OTLPEndpoint)
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			if provider == nil {
+				return nil
+			}
+			return provider.Shutdown(ctx)
+		},
+	}
+}
+
+// tracingMiddleware opens the server span, continuing any trace context the
+// caller sent. It runs after routing so spans are named by route template.
+func tracingMiddleware(next http.Handler) http.Handler {
+	return otelhttp.NewHandler(next, "", otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+		if route := mux.CurrentRoute(r); route != nil {
+			if template, err := route.GetPathTemplate(); err == nil {
+				return r.Method + " " + template
+			}
+		}
+		return r.Method
+	}))
+}
+
+// tracingHTTPClient records one client span per upstream call and propagates
+// the trace context to the provider. The query string is left out of the
+// span, and of the errors it records, because it carries the WeatherAPI key.
+type tracingHTTPClient struct {
+	next HTTPClient
+}
+
+func (c tracingHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	target := *req.URL
+	target.RawQuery = ""
+	ctx, span := otel.Tracer(tracerName).Start(req.Context(), req.Method+" "+req.URL.Host,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(req.Method),
+			semconv.ServerAddress(req.URL.Hostname()),
+			semconv.URLFull(target.String()),
+		))
+	defer span.End()
+	req = req.Clone(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	resp, err := c.next.Do(req)
+	if err != nil {
+		redacted := redactURLError(err)
+		span.RecordError(redacted)
+		span.SetStatus(codes.Error, redacted.Error())
+		return nil, err
+	}
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	if resp.StatusCode >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	return resp, nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestE2E_Tracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	env := newE2EEnv(t, nil)
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	resp, body := env.get(t, "/weather/01310100", map[string]string{
+		"traceparent": "00-" + traceID + "-00f067aa0ba902b7-01",
+	})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", resp.StatusCode, body)
+	}
+
+	var server sdktrace.ReadOnlySpan
+	var clients []sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		switch span.SpanKind() {
+		case trace.SpanKindServer:
+			server = span
+		case trace.SpanKindClient:
+			clients = append(clients, span)
+		}
+	}
+	if server == nil || server.Name() != "GET /weather/{cep}" {
+		t.Fatalf("Expected a server span named after the route, got %v", server)
+	}
+	if server.SpanContext().TraceID().String() != traceID {
+		t.Errorf("Expected the server span to continue trace %s, got %s", traceID, server.SpanContext().TraceID())
+	}
+	if len(clients) != 2 {
+		t.Fatalf("Expected one client span per upstream call, got %d", len(clients))
+	}
+	for _, span := range clients {
+		if span.Parent().SpanID() != server.SpanContext().SpanID() {
+			t.Errorf("Expected client span %q to be a child of the server span", span.Name())
+		}
+		for _, attr := range span.Attributes() {
+			if strings.Contains(attr.Value.Emit(), "e2e-key") {
+				t.Errorf("Expected the API key to stay out of span attributes, got %s=%s", attr.Key, attr.Value.Emit())
+			}
+		}
+	}
+}
+
+func TestTracingHTTPClient_RedactsErrors(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	const weatherURL = "http://127.0.0.1:1/v1/forecast.json?key=secret-key&q=Sao+Paulo"
+	mockClient := NewMockHTTPClient()
+	mockClient.AddError(weatherURL, &neturl.Error{Op: "Get", URL: weatherURL, Err: errors.New("connection refused")})
+	req, _ := http.NewRequest("GET", weatherURL, nil)
+	if _, err := (tracingHTTPClient{next: mockClient}).Do(req); err == nil {
+		t.Fatal("Expected the upstream error")
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("Expected one span, got %d", len(spans))
+	}
+	if strings.Contains(spans[0].Status().Description, "secret-key") {
+		t.Errorf("Expected the API key to stay out of the span status, got %q", spans[0].Status().Description)
+	}
+	for _, event := range spans[0].Events() {
+		for _, attr := range event.Attributes {
+			if strings.Contains(attr.Value.Emit(), "secret-key") {
+				t.Errorf("Expected the API key to stay out of span events, got %s=%s", attr.Key, attr.Value.Emit())
+			}
+		}
+	}
+}
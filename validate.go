@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	maxValidateBatch   = 1000
+	validateWorkers    = 8
+	maxValidateBodyLen = 1 << 20
+)
+
+type AddressValidateRequest struct {
+	CEPs []string `json:"ceps"`
+}
+
+// AddressValidationResult describes one input entry. Exists is omitted when
+// the providers could not be reached, since existence is then unknown.
+type AddressValidationResult struct {
+	Input   string   `json:"input"`
+	Valid   bool     `json:"valid"`
+	Exists  *bool    `json:"exists,omitempty"`
+	Address *Address `json:"address,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// handleAddressValidate checks a batch of CEPs for customer-database
+// cleanup. The body is JSON ({"ceps": [...]}) or CSV with the CEP in the
+// first column; the response is CSV when the client accepts text/csv.
+func (app *App) handleAddressValidate(w http.ResponseWriter, r *http.Request) {
+	ceps, err := readValidateInput(w, r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Message: err.Error()})
+		return
+	}
+	if len(ceps) > maxValidateBatch {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Message: fmt.Sprintf("too many ceps (max %d)", maxValidateBatch)})
+		return
+	}
+	results := app.validateCEPs(r.Context(), ceps)
+	if errors.Is(r.Context().Err(), context.DeadlineExceeded) {
+		writeJSON(w, http.StatusGatewayTimeout, ErrorResponse{Message: "upstream timeout"})
+		return
+	}
+	if r.Context().Err() != nil {
+		slog.InfoContext(r.Context(), "Client disconnected during address validation", "client_tag", clientTagFromContext(r.Context()))
+		return
+	}
+	slog.InfoContext(r.Context(), "Address validation served", "client_tag", clientTagFromContext(r.Context()), "ceps", len(ceps))
+	if strings.Contains(r.Header.Get("Accept"), "text/csv") {
+		writeValidationCSV(w, results)
+		return
+	}
+	writeJSON(w, http.StatusOK, results)
+}
+
+func readValidateInput(w http.ResponseWriter, r *http.Request) ([]string, error) {
+	body := http.MaxBytesReader(w, r.Body, maxValidateBodyLen)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "text/csv" {
+		reader := csv.NewReader(body)
+		reader.FieldsPerRecord = -1
+		records, err := reader.ReadAll()
+		if err != nil {
+			return nil, errors.New("invalid csv body")
+		}
+		var ceps []string
+		for i, record := range records {
+			cep := strings.TrimSpace(record[0])
+			// A header row is allowed; anything else is validated as a CEP.
+			if i == 0 && strings.EqualFold(cep, "cep") {
+				continue
+			}
+			ceps = append(ceps, cep)
+		}
+		return ceps, nil
+	}
+	var req AddressValidateRequest
+	if err := json.NewDecoder(body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		return nil, errors.New("invalid json body")
+	}
+	return req.CEPs, nil
+}
+
+// validateCEPs resolves the batch with a bounded number of concurrent
+// lookups, so a large upload does not flood the CEP providers. Lookups go
+// through the provider and therefore through its cache.
+func (app *App) validateCEPs(ctx context.Context, ceps []string) []AddressValidationResult {
+	results := make([]AddressValidationResult, len(ceps))
+	app.validationBacklog.Add(int64(len(ceps)))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < validateWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				results[idx] = app.validateCEP(ctx, ceps[idx])
+				app.validationBacklog.Add(-1)
+			}
+		}()
+	}
+	for idx := range ceps {
+		jobs <- idx
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+func (app *App) validateCEP(ctx context.Context, input string) AddressValidationResult {
+	result := AddressValidationResult{Input: input}
+	if !isValidCEP(input) {
+		result.Error = "invalid zipcode"
+		return result
+	}
+	result.Valid = true
+	address, err := app.cep.GetCEPInfo(ctx, normalizeCEP(input))
+	switch {
+	case errors.Is(err, ErrCEPNotFound):
+		exists := false
+		result.Exists = &exists
+		result.Error = "can not find zipcode"
+	case err != nil:
+		result.Error = "lookup failed"
+	default:
+		exists := true
+		result.Exists = &exists
+		result.Address = address
+	}
+	return result
+}
+
+func writeValidationCSV(w http.ResponseWriter, results []AddressValidationResult) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	out := csv.NewWriter(w)
+	out.Write([]string{"input", "valid", "exists", "cep", "street", "neighborhood", "city", "state", "ibge", "error"})
+	for _, result := range results {
+		exists := ""
+		if result.Exists != nil {
+			exists = strconv.FormatBool(*result.Exists)
+		}
+		address := result.Address
+		if address == nil {
+			address = &Address{}
+		}
+		out.Write([]string{result.Input, strconv.FormatBool(result.Valid), exists, address.CEP, address.Street,
+			address.Neighborhood, address.City, address.State, address.IBGE, result.Error})
+	}
+	out.Flush()
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func postValidate(t *testing.T, env *e2eEnv, contentType, accept, body string) (*http.Response, []byte) {
+	t.Helper()
+	req, err := http.NewRequest("POST", env.server.URL+"/address/validate", bytes.NewReader([]byte(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Accept", accept)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, respBody
+}
+
+func TestE2E_AddressValidate(t *testing.T) {
+	env := newE2EEnv(t, nil)
+
+	t.Run("Lote em JSON", func(t *testing.T) {
+		resp, body := postValidate(t, env, "application/json", "application/json", `{"ceps": ["01310-100", "123", "99999999", "20040002"]}`)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", resp.StatusCode, body)
+		}
+		var results []AddressValidationResult
+		if err := json.Unmarshal(body, &results); err != nil {
+			t.Fatalf("Error parsing response: %v", err)
+		}
+		if len(results) != 4 {
+			t.Fatalf("Expected 4 results, got %d", len(results))
+		}
+		if r := results[0]; !r.Valid || r.Exists == nil || !*r.Exists || r.Address == nil || r.Address.Street != "Avenida Paulista" {
+			t.Errorf("Expected 01310-100 to resolve, got %+v", r)
+		}
+		if r := results[1]; r.Valid || r.Exists != nil || r.Error != "invalid zipcode" {
+			t.Errorf("Expected 123 to be invalid, got %+v", r)
+		}
+		if r := results[2]; !r.Valid || r.Exists == nil || *r.Exists {
+			t.Errorf("Expected 99999999 to be valid but missing, got %+v", r)
+		}
+		if r := results[3]; r.Address == nil || r.Address.City != "Rio de Janeiro" {
+			t.Errorf("Expected results in input order, got %+v", r)
+		}
+	})
+
+	t.Run("Lote em CSV", func(t *testing.T) {
+		resp, body := postValidate(t, env, "text/csv", "text/csv", "cep,name\n01310100,Ana\n99999999,Bruno\n")
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", resp.StatusCode, body)
+		}
+		lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+		if len(lines) != 3 {
+			t.Fatalf("Expected header and 2 rows, got:\n%s", body)
+		}
+		if !strings.HasPrefix(lines[1], "01310100,true,true,01310100,Avenida Paulista") {
+			t.Errorf("Unexpected row for 01310100: %s", lines[1])
+		}
+		if !strings.HasPrefix(lines[2], "99999999,true,false,") {
+			t.Errorf("Unexpected row for 99999999: %s", lines[2])
+		}
+	})
+
+	t.Run("Corpo inválido", func(t *testing.T) {
+		if resp, body := postValidate(t, env, "application/json", "", `{"ceps": `); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d: %s", resp.StatusCode, body)
+		}
+	})
+
+	t.Run("Lote acima do limite", func(t *testing.T) {
+		ceps, _ := json.Marshal(AddressValidateRequest{CEPs: make([]string, maxValidateBatch+1)})
+		if resp, body := postValidate(t, env, "application/json", "", string(ceps)); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d: %s", resp.StatusCode, body)
+		}
+	})
+}
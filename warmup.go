@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+)
+
+// warmUp resolves each CEP and its weather so the first requests for them
+// are served from cache. Failures are logged and skipped: a cold entry is
+// not a reason to refuse to start.
+func (app *App) warmUp(ctx context.Context, ceps []string) int {
+	warmed := 0
+	for _, cep := range ceps {
+		if !isValidCEP(cep) {
+			slog.Warn("Skipping invalid warm-up CEP", "cep", cep)
+			continue
+		}
+		address, err := app.cep.GetCEPInfo(ctx, normalizeCEP(cep))
+		if err != nil {
+			slog.Warn("Error warming up CEP", "cep", cep, "error", err)
+			continue
+		}
+		if _, _, err := app.weather.LookupTemperature(ctx, address); err != nil {
+			slog.Warn("Error warming up weather", "cep", cep, "error", redactURLError(err))
+			continue
+		}
+		warmed++
+	}
+	return warmed
+}
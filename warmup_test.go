@@ -0,0 +1,25 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+)
+
+func TestWarmUp(t *testing.T) {
+	env := newE2EEnv(t, nil)
+
+	warmed := env.app.warmUp(context.Background(), []string{"01310-100", "123", "99999999", "20040002"})
+	if warmed != 2 {
+		t.Errorf("Expected 2 warmed CEPs, got %d", warmed)
+	}
+	calls := atomic.LoadInt32(&env.weatherAPI.calls)
+
+	resp, _ := env.get(t, "/weather/01310100", nil)
+	if resp.Header.Get("X-Cache") != "HIT" {
+		t.Errorf("Expected X-Cache HIT after warm-up, got %s", resp.Header.Get("X-Cache"))
+	}
+	if after := atomic.LoadInt32(&env.weatherAPI.calls); after != calls {
+		t.Errorf("Expected no WeatherAPI call after warm-up, got %d", after-calls)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// WatchdogPolicy sets how often the process is sampled and the levels that
+// log a warning. A zero Interval disables the watchdog.
+type WatchdogPolicy struct {
+	Interval      time.Duration
+	MaxGoroutines int
+	MaxQueueDepth int
+	MaxLag        time.Duration
+}
+
+type watchedQueue struct {
+	name  string
+	depth func() int
+}
+
+// watchdog samples goroutine count, background queue depths and scheduler
+// lag (how late its own ticker is picked up), so slow leaks show up in logs
+// and metrics long before the instance runs out of memory.
+type watchdog struct {
+	policy     WatchdogPolicy
+	queues     []watchedQueue
+	queueDepth *prometheus.GaugeVec
+	lag        prometheus.Gauge
+}
+
+func newWatchdog(policy WatchdogPolicy, metrics *Metrics) *watchdog {
+	w := &watchdog{
+		policy: policy,
+		queueDepth: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "projetodeploy_queue_depth",
+			Help: "Work waiting or in flight in background queues, by queue.",
+		}, []string{"queue"}),
+		lag: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "projetodeploy_scheduler_lag_seconds",
+			Help: "Delay between the watchdog tick firing and the watchdog running.",
+		}),
+	}
+	metrics.registry.MustRegister(w.queueDepth, w.lag)
+	return w
+}
+
+func (w *watchdog) watchQueue(name string, depth func() int) {
+	w.queues = append(w.queues, watchedQueue{name: name, depth: depth})
+}
+
+func (w *watchdog) check(lag time.Duration) {
+	w.lag.Set(lag.Seconds())
+	if lag > w.policy.MaxLag {
+		slog.Warn("Watchdog: scheduler lag above threshold", "lag", lag, "max_lag", w.policy.MaxLag)
+	}
+	if goroutines := runtime.NumGoroutine(); goroutines > w.policy.MaxGoroutines {
+		slog.Warn("Watchdog: goroutine count above threshold", "goroutines", goroutines, "max_goroutines", w.policy.MaxGoroutines)
+	}
+	for _, q := range w.queues {
+		depth := q.depth()
+		w.queueDepth.WithLabelValues(q.name).Set(float64(depth))
+		if depth > w.policy.MaxQueueDepth {
+			slog.Warn("Watchdog: queue depth above threshold", "queue", q.name, "depth", depth, "max_depth", w.policy.MaxQueueDepth)
+		}
+	}
+}
+
+func (w *watchdog) component() Component {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	return Component{
+		Name: "watchdog",
+		Start: func(ctx context.Context) error {
+			go func() {
+				defer close(done)
+				ticker := time.NewTicker(w.policy.Interval)
+				defer ticker.Stop()
+				for {
+					select {
+					case tick := <-ticker.C:
+						w.check(time.Since(tick))
+					case <-stop:
+						return
+					}
+				}
+			}()
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			close(stop)
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatchdog_Check(t *testing.T) {
+	var logs bytes.Buffer
+	logger, _ := newLogger(&logs, "info", "console")
+	defaultLogger := slog.Default()
+	slog.SetDefault(logger)
+	t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+
+	metrics := newMetrics()
+	w := newWatchdog(WatchdogPolicy{Interval: time.Second, MaxGoroutines: 100000, MaxQueueDepth: 10, MaxLag: 50 * time.Millisecond}, metrics)
+	w.watchQueue("weather_refresh", func() int { return 3 })
+	w.watchQueue("address_validation", func() int { return 25 })
+	w.check(200 * time.Millisecond)
+
+	tests := []struct {
+		name     string
+		output   string
+		expected string
+		present  bool
+	}{
+		{"Fila acima do limite gera aviso", logs.String(), "queue=address_validation depth=25", true},
+		{"Fila dentro do limite não gera aviso", logs.String(), "queue=weather_refresh", false},
+		{"Atraso do escalonador gera aviso", logs.String(), "scheduler lag above threshold", true},
+		{"Goroutines dentro do limite", logs.String(), "goroutine count above threshold", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if strings.Contains(tt.output, tt.expected) != tt.present {
+				t.Errorf("Expected %q present=%v in logs:\n%s", tt.expected, tt.present, tt.output)
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	metrics.handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	for _, line := range []string{`projetodeploy_queue_depth{queue="address_validation"} 25`, "projetodeploy_scheduler_lag_seconds 0.2"} {
+		if !strings.Contains(rec.Body.String(), line) {
+			t.Errorf("Expected %q in /metrics", line)
+		}
+	}
+}
+
+func TestWatchdog_Component(t *testing.T) {
+	w := newWatchdog(WatchdogPolicy{Interval: 10 * time.Millisecond, MaxGoroutines: 100000, MaxQueueDepth: 10, MaxLag: time.Second}, newMetrics())
+	checked := make(chan struct{}, 1)
+	w.watchQueue("test", func() int {
+		select {
+		case checked <- struct{}{}:
+		default:
+		}
+		return 0
+	})
+	component := w.component()
+	if err := component.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-checked:
+	case <-time.After(time.Second):
+		t.Error("Expected the watchdog to sample queues on its interval")
+	}
+	if err := component.Stop(context.Background()); err != nil {
+		t.Errorf("Expected clean stop, got %v", err)
+	}
+}